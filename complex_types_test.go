@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"os"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -63,3 +66,22 @@ func TestComplexTypes(t *testing.T) {
 	e.Encode(schema)
 
 }
+
+func TestReflectRootEnum(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(EnumAlign(""))
+
+	a.Equal(tTypeString, schema.Type.Type)
+	a.Equal([]interface{}{enumAlignCenter}, schema.Enum)
+	a.Equal(Version, schema.Version)
+	a.Empty(schema.Ref)
+	a.Empty(schema.Definitions)
+
+	schema = Reflect(implicitOneOfImpl{})
+
+	r.Len(schema.OneOf, 3)
+	a.Equal(tTypeString, schema.Type.Type)
+	a.Equal(Version, schema.Version)
+}
@@ -7,7 +7,10 @@
 package jsonschema
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
+	"unicode"
 )
 
 const (
@@ -17,29 +20,363 @@ const (
 	tTypeNumber  = "number"
 	tTypeBoolean = "boolean"
 	tTypeArray   = "array"
+	tTypeNull    = "null"
 )
 
-// Reflect reflects to Schema from a value.
+// Placeholders of definitions whose struct is being reflected.
+var (
+	definitionInProgress = &Type{}
+	definitionReferenced = &Type{} // in progress and referenced by itself
+)
+
+// Reflector reflects Go values into a Schema. The zero value is ready to use.
+type Reflector struct {
+	// PropertyNameTag is the struct tag property names are read from,
+	// e.g. "yaml", "bson" or "mapstructure". Defaults to "json".
+	PropertyNameTag string
+
+	// StructTagName configures in one place the struct tags property names
+	// and schema keywords are read from. Its Property tag takes precedence
+	// over PropertyNameTag.
+	StructTagName StructTagNames
+
+	// StandaloneTags also reads schema keywords from struct tags of their
+	// own, such as `minimum:"1"`, after the keywords tag. They are ignored
+	// by default since tags of other libraries may share their names.
+	StandaloneTags bool
+
+	// FieldNameCase names fields without a property name tag, which are
+	// left out by default.
+	FieldNameCase NameCase
+
+	// InterfaceImplementations lists the known implementations of interface
+	// types. Fields of a registered interface type reflect to a oneOf of the
+	// implementations' schemas.
+	InterfaceImplementations map[reflect.Type][]reflect.Type
+
+	// enums holds the values registered with RegisterEnum.
+	enums map[reflect.Type][]interface{}
+
+	// Discriminators names, per registered interface type, the property whose
+	// const value tells the implementations apart. The oneOf of such an
+	// interface carries a discriminator mapping the values to the variants.
+	Discriminators map[reflect.Type]string
+
+	// DescriptionFromTitle copies a field's title into its description when
+	// the description is empty, for tag conventions documenting via title.
+	DescriptionFromTitle bool
+
+	// SwapTitleDescription exchanges a field's title and description.
+	// It takes precedence over DescriptionFromTitle.
+	SwapTitleDescription bool
+
+	// EmbeddedAsAllOf references embedded structs from an allOf instead of
+	// flattening their properties into the embedding struct.
+	EmbeddedAsAllOf bool
+
+	// Nullable selects how pointers allow null, which they marshal to when
+	// nil. By default they are reflected like the value they point to.
+	Nullable NullableStyle
+
+	// NullableOmitEmptySlices allows null for slice fields tagged omitempty.
+	// encoding/json omits those when empty, but other encoders write a nil
+	// slice as null, as encoding/json does for slices without omitempty.
+	NullableOmitEmptySlices bool
+
+	// Draft selects the $schema URI written at the root, the keyword
+	// definitions are referenced under and the form of exclusive bounds.
+	// Defaults to draft-07.
+	Draft Draft
+
+	// BaseSchemaID is written as the root $id and prefixes definition
+	// references, namespacing them when schemas are bundled.
+	BaseSchemaID string
+
+	// DurationAsString reflects time.Duration as an ISO 8601 duration string
+	// instead of an integer of nanoseconds.
+	DurationAsString bool
+
+	// TimestampInteger reflects time.Time as an integer of seconds since
+	// the unix epoch, for codecs marshaling times as unix timestamps.
+	TimestampInteger bool
+
+	// NumberAsString reflects int64, uint64 and float64 as strings holding
+	// the number, for consumers losing precision beyond 2^53.
+	NumberAsString bool
+
+	// EmitNumberFormats sets the OpenAPI formats of numbers, float for
+	// float32 and double for float64, and int32 and int64 for integers of
+	// those kinds.
+	EmitNumberFormats bool
+
+	// UseJSONSchemaInterface lets types implementing JSONSchema() *Type
+	// provide their own schema instead of having it reflected.
+	UseJSONSchemaInterface bool
+
+	// SanitizeRefs escapes definition names in references as JSON Pointer
+	// tokens, for names containing "/" or "~" such as package paths.
+	SanitizeRefs bool
+
+	// WrapRefAnnotations wraps references annotated with keywords such as
+	// a title, readOnly or default in an allOf, for drafts ignoring
+	// keywords next to $ref.
+	// Otherwise annotations are emitted alongside $ref.
+	WrapRefAnnotations bool
+
+	// NoDefaults leaves out the defaults reflected from values, such as
+	// the zero values of fields. Defaults set by a default tag or a
+	// Default() method are kept.
+	NoDefaults bool
+
+	// RequiredByDefault requires every field, including omitempty ones,
+	// unless it is tagged required=false.
+	RequiredByDefault bool
+
+	// ExpandStructs inlines nested structs instead of referencing them
+	// from Definitions.
+	ExpandStructs bool
+
+	// DoNotReference inlines a copy of the definition of nested structs
+	// instead of a $ref. Only structs referring to themselves are kept in
+	// Definitions, to end the recursion.
+	DoNotReference bool
+
+	// DisallowAdditionalProperties closes struct objects with
+	// "additionalProperties": false, rejecting keys no field declares.
+	// Structs embedding others under EmbeddedAsAllOf, and the embedded
+	// structs, stay open since allOf can't close an object across branches.
+	DisallowAdditionalProperties bool
+
+	// StrictAdditionalProperties closes map objects with
+	// "additionalProperties": false, so only keys matching their
+	// patternProperties are allowed. The pattern matches the keys the key
+	// type marshals to, such as integers or enum values, or the
+	// propertyNames tag; maps of arbitrary string keys still allow any.
+	StrictAdditionalProperties bool
+
+	// TupleArrays reflects fixed size arrays as tuples, listing a schema
+	// per position in an items array, or in prefixItems for draft 2020-12.
+	TupleArrays bool
+
+	// AlwaysEmitRequired writes "required": [] on struct objects without
+	// required fields, for validators telling it apart from no keyword.
+	AlwaysEmitRequired bool
+
+	// MinLengthForRequiredArrays requires at least one item in required
+	// array fields, unless their tags set minItems.
+	MinLengthForRequiredArrays bool
+
+	// DefinitionNameFromJSONTag keys definitions by the snake_cased type
+	// name, e.g. "user_profile" for UserProfile, matching json-ish naming.
+	DefinitionNameFromJSONTag bool
+
+	// RequiredTagKeys lists further struct tags marking a field required
+	// when their options contain "required", e.g. "binding" for Gin.
+	RequiredTagKeys []string
+
+	// OnField is called with every exported struct field before its tags
+	// are parsed. It drops the field by returning false, and renames it by
+	// returning a non-empty name.
+	OnField func(reflect.StructField) (name string, include bool)
+
+	// OnType is called with every reflected Go type and its schema,
+	// which it may modify.
+	OnType func(reflect.Type, *Type)
+}
+
+// Clone returns an independent copy of the Reflector, so configured
+// variants can be derived without affecting the original.
+func (r *Reflector) Clone() *Reflector {
+	clone := *r
+	clone.RequiredTagKeys = append([]string(nil), r.RequiredTagKeys...)
+
+	if r.InterfaceImplementations != nil {
+		clone.InterfaceImplementations = make(map[reflect.Type][]reflect.Type, len(r.InterfaceImplementations))
+		for iface, implementations := range r.InterfaceImplementations {
+			clone.InterfaceImplementations[iface] = append([]reflect.Type(nil), implementations...)
+		}
+	}
+
+	if r.enums != nil {
+		clone.enums = make(map[reflect.Type][]interface{}, len(r.enums))
+		for t, values := range r.enums {
+			clone.enums[t] = append([]interface{}(nil), values...)
+		}
+	}
+
+	if r.Discriminators != nil {
+		clone.Discriminators = make(map[reflect.Type]string, len(r.Discriminators))
+		for iface, property := range r.Discriminators {
+			clone.Discriminators[iface] = property
+		}
+	}
+
+	return &clone
+}
+
+// RegisterEnum lists the values of t, such as the constants of a
+// `type Status string`, so it reflects to an enum without implementing
+// Enum() []interface{}.
+func (r *Reflector) RegisterEnum(t reflect.Type, values ...interface{}) {
+	if r.enums == nil {
+		r.enums = map[reflect.Type][]interface{}{}
+	}
+
+	r.enums[t] = append(r.enums[t], values...)
+}
+
+// NameCase controls how fields without a property name tag are named.
+type NameCase int
+
+const (
+	// NameCaseSkip leaves fields without a property name tag out.
+	NameCaseSkip NameCase = iota
+	// NameCasePreserve names fields as declared, e.g. PascalCase, like
+	// encoding/json does.
+	NameCasePreserve
+	// NameCaseLower names fields by their lowercased name.
+	NameCaseLower
+)
+
+// NullableStyle controls how pointers allow null.
+type NullableStyle int
+
+const (
+	// NullableNone reflects pointers like the value they point to.
+	NullableNone NullableStyle = iota
+	// NullableTypeList adds "null" to the type keyword, and references
+	// to an anyOf with a null type.
+	NullableTypeList
+	// NullableOpenAPI sets the OpenAPI 3.0 nullable keyword, and wraps
+	// references in an allOf to carry it.
+	NullableOpenAPI
+)
+
+func (c NameCase) apply(name string) string {
+	switch c {
+	case NameCasePreserve:
+		return name
+	case NameCaseLower:
+		return strings.ToLower(name)
+	}
+
+	return ""
+}
+
+// StructTagNames names the struct tags reflection reads. Empty names use
+// the defaults.
+type StructTagNames struct {
+	// Property is the tag property names and options such as omitempty are
+	// read from. Defaults to "json".
+	Property string
+
+	// Keywords is the tag schema keywords such as required or minimum are
+	// read from. Defaults to "jsonschema".
+	Keywords string
+}
+
+// Reflect reflects to Schema from a value using a default Reflector.
 func Reflect(v interface{}) *Schema {
-	valueOf := reflect.ValueOf(v)
-	typeOf := reflect.TypeOf(v)
+	return (&Reflector{}).Reflect(v)
+}
 
-	valueOf = reflect.Indirect(valueOf)
+// ReflectFromType reflects to Schema from a type using a default Reflector.
+func ReflectFromType(t reflect.Type) *Schema {
+	return (&Reflector{}).ReflectFromType(t)
+}
 
+// Reflect reflects to Schema from a value.
+func (r *Reflector) Reflect(v interface{}) *Schema {
+	return r.reflectRoot(reflect.TypeOf(v), reflect.Indirect(reflect.ValueOf(v)))
+}
+
+// ReflectFromType reflects to Schema from a type, for callers without an
+// instance, e.g. types from a registry. It reflects the zero value, so
+// only defaults of tags and Default() methods are set.
+func (r *Reflector) ReflectFromType(t reflect.Type) *Schema {
+	return r.reflectRoot(t, reflect.Indirect(reflect.Zero(t)))
+}
+
+func (r *Reflector) reflectRoot(typeOf reflect.Type, valueOf reflect.Value) *Schema {
 	definitions := Definitions{}
 
-	root := reflectType(definitions, typeOf, valueOf, true)
-	root.Version = Version
+	root := r.reflectType(definitions, typeOf, valueOf, true)
+	root.Version = r.Draft.URI()
+	root.ID = r.BaseSchemaID
+
+	schema := &Schema{Type: root, Definitions: definitions, Draft: r.Draft}
 
-	return &Schema{Type: root, Definitions: definitions}
+	complete := func(t *Type) {
+		r.Draft.exclusiveBounds(t)
+		discriminate(definitions, t)
+	}
+	root.walk(complete)
+	for _, def := range definitions {
+		def.walk(complete)
+	}
+
+	// inlined structs leave only the definitions of recursive ones in use
+	if r.DoNotReference {
+		schema.Prune()
+	}
+
+	return schema
+}
+
+// ReflectInto reflects v, registering the definitions it needs in a
+// definitions bundle shared by several schemas, and returns the root type.
+// It fails without changing the bundle when a definition of the same name
+// but a different structure is already registered.
+func (r *Reflector) ReflectInto(definitions Definitions, v interface{}) (*Type, error) {
+	schema := r.Reflect(v)
+
+	for name, def := range schema.Definitions {
+		if existing, ok := definitions[name]; ok && !existing.Equal(def) {
+			return nil, fmt.Errorf("jsonschema: conflicting definitions of %q", name)
+		}
+	}
+
+	for name, def := range schema.Definitions {
+		definitions[name] = def
+	}
+
+	schema.Type.Version = ""
+
+	return schema.Type, nil
 }
 
-func reflectType(definitions Definitions, t reflect.Type, v reflect.Value, root bool) *Type {
-	if v.Kind() == reflect.Ptr {
-		v = v.Elem() // deref ptr
+func (r *Reflector) reflectType(definitions Definitions, t reflect.Type, v reflect.Value, root bool) *Type {
+	typ := r.dispatch(definitions, t, v, root)
+
+	if typ != nil {
+		if r.NoDefaults {
+			typ.Default = nil
+		}
+		applyDefaulter(typ, t, v)
+
+		if !root && t.Kind() == reflect.Ptr {
+			typ = r.nullable(typ)
+		}
+	}
+
+	if r.OnType != nil && typ != nil {
+		r.OnType(t, typ)
+	}
+
+	return typ
+}
+
+// dispatch reflects t by its special type, implemented interfaces or kind.
+func (r *Reflector) dispatch(definitions Definitions, t reflect.Type, v reflect.Value, root bool) *Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem() // deref ptr
+		}
 
 		if !v.IsValid() {
-			v = reflect.Zero(t.Elem()) // create zero value
+			v = reflect.Zero(t) // create zero value
 		}
 	}
 
@@ -47,70 +384,120 @@ func reflectType(definitions Definitions, t reflect.Type, v reflect.Value, root
 		v = reflect.Indirect(v.Elem())
 	}
 
+	if r.UseJSONSchemaInterface && t.Implements(typeSchemaProvider) && v.IsValid() {
+		if typ := r.reflectSchemaProvider(definitions, v); typ != nil {
+			return typ
+		}
+	}
+
+	if values, ok := r.enums[t]; ok {
+		return r.reflectRegisteredEnum(definitions, v, values)
+	}
+
+	if implementations, ok := r.InterfaceImplementations[t]; ok {
+		return r.reflectImplementations(definitions, t, implementations)
+	}
+
 	switch t {
 	case typeTime:
-		return reflectTime(definitions, v)
+		return r.reflectTime(definitions, v)
 	case typeIP:
-		return reflectIP(definitions, v)
+		return r.reflectIP(definitions, v)
 	case typeURI:
-		return reflectURI(definitions, v)
+		return r.reflectURI(definitions, v)
+	case typeRegexp:
+		return r.reflectRegexp(definitions, v)
+	case typeDuration:
+		return r.reflectDuration(definitions, v)
+	case typeRawJSON:
+		// arbitrary JSON rather than the base64 of a byte slice
+		return &Type{}
+	case typeSyncMap, typeMutex, typeRWMutex, typeWaitGroup, typeOnce:
+		return r.reflectOpaque(definitions, v)
 	}
 
 	switch true {
 	case t.Implements(typePBEnum):
-		return reflectPBEnum(definitions, v)
+		return r.reflectPBEnum(definitions, v)
 
 	case t.Implements(typeOneOf):
-		return reflectOneOf(definitions, v)
+		return r.reflectOneOf(definitions, v)
 
 	case t.Implements(typeAnyOf):
-		return reflectAnyOf(definitions, v)
+		return r.reflectAnyOf(definitions, v)
 
 	case t.Implements(typeAllOf):
-		return reflectAllOf(definitions, v)
+		return r.reflectAllOf(definitions, v)
 
 	case t.Implements(typeEnum):
-		return reflectEnum(definitions, v)
+		return r.reflectEnum(definitions, v)
+
+	case t.Implements(typeTextMarshaler) && !t.Implements(typeJSONMarshaler):
+		return r.reflectTextMarshaler(definitions, v)
+
+	case t.Implements(typeBinaryMarshaler) && !t.Implements(typeJSONMarshaler):
+		return r.reflectBinaryMarshaler(definitions, v)
 	}
 
 	switch v.Kind() {
 	case reflect.Struct:
-		currentType := reflectStruct(definitions, v)
-		if root {
-			return currentType
+		// anonymous structs have no name to be referenced by
+		if root || v.Type().Name() == "" {
+			return r.reflectStruct(definitions, v)
+		}
+
+		name := r.definitionName(v.Type())
+
+		if r.ExpandStructs {
+			return r.expandStruct(definitions, name, v)
 		}
 
-		definitions[v.Type().Name()] = currentType
+		// a registered definition may still be in progress when the struct
+		// refers to itself, so reference it instead of recursing
+		def, ok := definitions[name]
+		if !ok {
+			definitions[name] = definitionInProgress
+			def = r.reflectStruct(definitions, v)
+			definitions[name] = def
+		}
+
+		if r.DoNotReference && def != definitionInProgress {
+			return def.deepCopy() // field annotations must not leak into the definition
+		}
 
-		return newReference(v.Type().Name())
+		return r.newReference(name)
 
-	case reflect.Slice:
-		return reflectSlice(definitions, v)
+	case reflect.Slice, reflect.Array:
+		return r.reflectSlice(definitions, v)
 
 	case reflect.Map:
-		return reflectMap(definitions, v)
+		return r.reflectMap(definitions, v)
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 
-		return reflectInteger(definitions, v)
+		return r.reflectInteger(definitions, v)
 
 	case reflect.Float32, reflect.Float64:
-		return reflectNumber(definitions, v)
+		return r.reflectNumber(definitions, v)
 
 	case reflect.Bool:
-		return reflectBool(definitions, v)
+		return r.reflectBool(definitions, v)
 
 	case reflect.String:
-		return reflectString(definitions, v)
+		return r.reflectString(definitions, v)
 	}
 
-	return reflectInterface(definitions, t, v)
+	return r.reflectInterface(definitions, t, v)
 }
 
-func reflectStruct(definitions Definitions, v reflect.Value) *Type {
+func (r *Reflector) reflectStruct(definitions Definitions, v reflect.Value) *Type {
 	var currentType = newType(tTypeObject)
 
+	// properties of fields declared by the struct itself, which take
+	// precedence over promoted fields of embedded structs like in encoding/json
+	declared := map[string]bool{}
+
 	for i := 0; i < v.NumField(); i++ {
 		structField := v.Type().Field(i)
 		structValue := v.Field(i)
@@ -120,9 +507,29 @@ func reflectStruct(definitions Definitions, v reflect.Value) *Type {
 			continue
 		}
 
-		// embedded field
-		if isAnonymous(structField) {
-			typ := reflectType(definitions, structField.Type, structValue, false)
+		// request scoped values never belong to a schema
+		if isContext(structField) {
+			continue
+		}
+
+		var fieldName string
+		if r.OnField != nil {
+			var include bool
+			if fieldName, include = r.OnField(structField); !include {
+				continue
+			}
+		}
+
+		// embedded field; encoding/json names an embedded map after its type
+		if isAnonymous(structField) && !isMap(structField.Type) {
+			if r.EmbeddedAsAllOf && isStruct(structField.Type) {
+				base := r.reflectType(definitions, structField.Type, structValue, false)
+				currentType.AllOf = append(currentType.AllOf, r.openEmbedded(definitions, base))
+				continue
+			}
+
+			// reflect as root to get the struct inline for flattening
+			typ := r.reflectType(definitions, structField.Type, structValue, true)
 			if typ.Type != tTypeObject && v.NumField() == 1 {
 				return typ
 			}
@@ -130,31 +537,213 @@ func reflectStruct(definitions Definitions, v reflect.Value) *Type {
 				definitions[def] = info
 			}
 
+			prefix := r.parseTags(structField.Tag).prefix
 			for def, info := range typ.Properties {
-				currentType.Properties[def] = info
+				if !declared[prefix+def] {
+					currentType.Properties[prefix+def] = info
+				}
+			}
+			for _, name := range typ.Required {
+				if !declared[prefix+name] {
+					currentType.AddRequired(prefix + name)
+				}
 			}
 			continue
 		}
 
-		tags := parseTags(structField.Tag)
+		tags := r.parseTags(structField.Tag)
+		if fieldName != "" {
+			tags.name = fieldName
+		}
+		if tags.name == "" && !tags.ignored {
+			tags.name = r.FieldNameCase.apply(structField.Name)
+		}
 		if isIgnored(tags) {
 			continue
 		}
 
-		fieldType := reflectType(definitions, structField.Type, structValue, false)
+		var fieldType *Type
+		switch {
+		case tags.noBinary && isBytes(structField.Type):
+			fieldType = r.reflectArray(definitions, structValue)
+		case tags.binary && isByteArray(structField.Type):
+			fieldType = reflectBinaryArray(structField.Type)
+		}
+		if fieldType == nil {
+			fieldType = r.reflectType(definitions, structField.Type, structValue, false)
+		}
 		if fieldType == nil {
 			continue
 		}
 
-		applyInfo(fieldType, tags)
+		r.applyInfo(fieldType, tags)
 		applyValidation(fieldType, tags)
 
+		if tags.asString {
+			fieldType = quoteType(fieldType, structField.Type)
+		}
+
+		// the tags are applied after reflectType allowed null for pointers
+		if r.Nullable != NullableNone && structField.Type.Kind() == reflect.Ptr {
+			allowNullValue(fieldType)
+		}
+
+		if r.MinLengthForRequiredArrays && tags.required && fieldType.Type == tTypeArray && fieldType.MinItems == nil {
+			fieldType.MinItems = intPtr(1)
+		}
+
+		if r.NullableOmitEmptySlices && tags.omitEmpty && isSlice(structField.Type) {
+			fieldType = nullableTypeList(fieldType)
+		}
+
+		fieldType = r.wrapRef(fieldType)
+
 		currentType.Properties[tags.name] = fieldType
+		declared[tags.name] = true
+
+		if tags.required {
+			currentType.AddRequired(tags.name)
+		} else {
+			currentType.removeRequired(tags.name)
+		}
+	}
+
+	// an allOf branch doesn't see the properties of the others, so closing
+	// the object would reject the embedded ones
+	if r.DisallowAdditionalProperties && currentType.AdditionalProperties == nil && len(currentType.AllOf) == 0 {
+		currentType.AdditionalProperties = []byte("false")
+	}
+
+	if r.AlwaysEmitRequired && currentType.Required == nil {
+		currentType.Required = []string{}
 	}
 
+	applyPropertiesRange(currentType, v)
+	applyLinks(currentType, v)
+
 	return currentType
 }
 
+// openEmbedded reopens the struct an allOf branch refers to when
+// DisallowAdditionalProperties closed it, as it would otherwise reject the
+// properties of the struct embedding it.
+func (r *Reflector) openEmbedded(definitions Definitions, base *Type) *Type {
+	if !r.DisallowAdditionalProperties {
+		return base
+	}
+
+	typ := base
+	if name, ok := definitionName(base.Ref); ok && definitions[name] != nil {
+		typ = definitions[name]
+	}
+	if string(typ.AdditionalProperties) == "false" {
+		typ.AdditionalProperties = nil
+	}
+
+	return base
+}
+
+// expandStruct reflects a struct inline, unless it refers to itself: such
+// a struct is registered in definitions and referenced to end the recursion.
+func (r *Reflector) expandStruct(definitions Definitions, name string, v reflect.Value) *Type {
+	switch definitions[name] {
+	case nil:
+	case definitionInProgress:
+		definitions[name] = definitionReferenced
+		return r.newReference(name)
+	default:
+		return r.newReference(name)
+	}
+
+	definitions[name] = definitionInProgress
+	typ := r.reflectStruct(definitions, v)
+
+	if definitions[name] == definitionInProgress {
+		delete(definitions, name)
+		return typ
+	}
+
+	definitions[name] = typ
+
+	return r.newReference(name)
+}
+
+// wrapRef moves the keywords next to a reference, such as its title,
+// readOnly or default, into an allOf wrapper, for drafts ignoring keywords
+// next to $ref.
+func (r *Reflector) wrapRef(typ *Type) *Type {
+	if !r.WrapRefAnnotations || typ.Ref == "" {
+		return typ
+	}
+
+	ref := &Type{Ref: typ.Ref}
+	if reflect.DeepEqual(typ, ref) {
+		return typ
+	}
+
+	wrapper := *typ
+	wrapper.Ref = ""
+	wrapper.AllOf = append([]*Type{ref}, typ.AllOf...)
+
+	return &wrapper
+}
+
+// definitionName returns the key t is registered under in Definitions.
+func (r *Reflector) definitionName(t reflect.Type) string {
+	if r.DefinitionNameFromJSONTag {
+		return snakeCase(t.Name())
+	}
+
+	return t.Name()
+}
+
+// snakeCase converts a Go identifier to snake_case, keeping acronyms
+// together, e.g. "HTTPServer" becomes "http_server".
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+
+	for i, c := range runes {
+		if unicode.IsUpper(c) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
+
+func (r *Reflector) newReference(name string) *Type {
+	if r.SanitizeRefs {
+		name = escapeJSONPointer(name)
+	}
+
+	return &Type{Ref: r.BaseSchemaID + r.Draft.definitionsPrefix() + name}
+}
+
+func (r *Reflector) propertyNameTag() string {
+	switch {
+	case r.StructTagName.Property != "":
+		return r.StructTagName.Property
+	case r.PropertyNameTag != "":
+		return r.PropertyNameTag
+	}
+
+	return tagNameJson
+}
+
+func (r *Reflector) keywordsTag() string {
+	if r.StructTagName.Keywords != "" {
+		return r.StructTagName.Keywords
+	}
+
+	return tagNamespace
+}
+
 func isUnexported(field reflect.StructField) bool {
 	return field.PkgPath != ""
 }
@@ -162,3 +751,31 @@ func isUnexported(field reflect.StructField) bool {
 func isAnonymous(field reflect.StructField) bool {
 	return field.Anonymous
 }
+
+func isContext(field reflect.StructField) bool {
+	return field.Type.Implements(typeContext)
+}
+
+func isSlice(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Slice
+}
+
+func isMap(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Map
+}
+
+func isStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct
+}
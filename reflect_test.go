@@ -1,9 +1,15 @@
 package jsonschema
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"net"
 	"net/url"
 	"reflect"
+	"regexp"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -62,6 +68,14 @@ type ProtoEnum int32
 
 func (ProtoEnum) EnumDescriptor() ([]byte, []int) { return []byte(nil), []int{0} }
 
+func (ProtoEnum) EnumValues() map[string]int32 {
+	return map[string]int32{"Unset": 0, "Great": 1}
+}
+
+type legacyProtoEnum int32
+
+func (legacyProtoEnum) EnumDescriptor() ([]byte, []int) { return []byte(nil), []int{0} }
+
 const (
 	Unset ProtoEnum = iota
 	Great
@@ -117,14 +131,16 @@ func TestEmbeddedTypes(t *testing.T) {
 
 	assert.NotNil(t, schema.Definitions["ColorPicker"])
 	assert.Equal(t, tTypeString, schema.Definitions["ColorPicker"].Type)
-	assert.Equal(t, String(""), schema.Definitions["ColorPicker"].Default)
+	assert.Nil(t, schema.Definitions["ColorPicker"].Default)
 
 	assert.NotNil(t, schema.Definitions["TextArea"])
 	assert.Equal(t, tTypeString, schema.Definitions["TextArea"].Type)
-	assert.Equal(t, String(""), schema.Definitions["TextArea"].Default)
+	assert.Nil(t, schema.Definitions["TextArea"].Default)
 }
 
 func TestReflect(t *testing.T) {
+	reflector := &Reflector{}
+
 	t.Run("ReflectStruct_returns_CorrectType", func(t *testing.T) {
 		a := assert.New(t)
 		r := require.New(t)
@@ -159,7 +175,7 @@ func TestReflect(t *testing.T) {
 
 		r.Contains(grandfatherType.Properties, "family_name")
 		a.Equal(grandfatherType.Properties["family_name"].Type, tTypeString)
-		a.Equal(tu.Grandfather.FamilyName, grandfatherType.Properties["family_name"].Default)
+		a.Nil(grandfatherType.Properties["family_name"].Default)
 
 		r.Contains(schema.Properties, "id")
 		idProperty := schema.Properties["id"]
@@ -182,28 +198,28 @@ func TestReflect(t *testing.T) {
 		r.Contains(schema.Properties, "birth_date")
 		birthDateProperty := schema.Properties["birth_date"]
 		a.Equal(tTypeString, birthDateProperty.Type)
-		//a.Equal("date-time", birthDateProperty.Format)
+		a.Equal("date-time", birthDateProperty.Format)
 
 		r.Contains(schema.Properties, "website")
 		websiteProperty := schema.Properties["website"]
 		a.Equal(tTypeString, websiteProperty.Type)
-		//a.Equal("uri", websiteProperty.Format)
+		a.Equal("uri", websiteProperty.Format)
 
 		r.Contains(schema.Properties, "network_address")
 		networkAddressProperty := schema.Properties["network_address"]
 		a.Equal(tTypeString, networkAddressProperty.Type)
-		//a.Equal("ipv4", networkAddressProperty.Format)
+		a.Equal("ipv4", networkAddressProperty.Format)
 
 		r.Contains(schema.Properties, "photo")
 		photoProperty := schema.Properties["photo"]
 		a.Equal(tTypeString, photoProperty.Type)
-		//a.Equal("base64", photoProperty.Media.BinaryEncoding)
+		r.NotNil(photoProperty.Media)
+		a.Equal("base64", photoProperty.Media.BinaryEncoding)
 
-		// TODO: implement
-		//r.Contains(schema.Properties, "feeling")
-		//feelingProperty := schema.Properties["feeling"]
-		//a.Equal(tTypeArray, feelingProperty.Type)
-		//a.Equal("base64", feelingProperty.Format)
+		r.Contains(schema.Properties, "feeling")
+		feelingProperty := schema.Properties["feeling"]
+		a.Equal(tTypeInteger, feelingProperty.Type)
+		a.Equal([]interface{}{int32(0), int32(1)}, feelingProperty.Enum)
 
 		r.Contains(schema.Properties, "age")
 		ageProperty := schema.Properties["age"]
@@ -235,17 +251,36 @@ func TestReflect(t *testing.T) {
 		d := Definitions{}
 		v := reflect.ValueOf(time.Now())
 
-		typ := reflectTime(d, v)
+		typ := reflector.reflectTime(d, v)
 		require.NotNil(t, typ)
 
 		assert.Equal(t, typ.Type, tTypeString)
 		assert.Equal(t, typ.Format, "date-time")
 	})
+	t.Run("ReflectTime_returns_StringDefault", func(t *testing.T) {
+		d := Definitions{}
+		tm := time.Date(2019, 5, 1, 12, 30, 0, 0, time.UTC)
+		v := reflect.ValueOf(tm)
+
+		typ := reflector.reflectTime(d, v)
+		require.NotNil(t, typ)
+
+		assert.Equal(t, "2019-05-01T12:30:00Z", typ.Default)
+	})
+	t.Run("ReflectTime_returns_NoDefaultOnZeroTime", func(t *testing.T) {
+		d := Definitions{}
+		v := reflect.ValueOf(time.Time{})
+
+		typ := reflector.reflectTime(d, v)
+		require.NotNil(t, typ)
+
+		assert.Nil(t, typ.Default)
+	})
 	t.Run("ReflectIP_returns_ValidType", func(t *testing.T) {
 		d := Definitions{}
 		v := reflect.ValueOf(net.IP{})
 
-		typ := reflectIP(d, v)
+		typ := reflector.reflectIP(d, v)
 		require.NotNil(t, typ)
 
 		assert.Equal(t, typ.Type, tTypeString)
@@ -255,19 +290,53 @@ func TestReflect(t *testing.T) {
 		d := Definitions{}
 		v := reflect.ValueOf(url.URL{})
 
-		typ := reflectURI(d, v)
+		typ := reflector.reflectURI(d, v)
 		require.NotNil(t, typ)
 
 		assert.Equal(t, typ.Type, tTypeString)
 		assert.Equal(t, typ.Format, "uri")
 	})
+	t.Run("ReflectRegexp_returns_ValidType", func(t *testing.T) {
+		d := Definitions{}
+		v := reflect.ValueOf(regexp.MustCompile("^[a-z]+$"))
+
+		typ := reflector.reflectRegexp(d, v)
+		require.NotNil(t, typ)
+
+		assert.Equal(t, typ.Type, tTypeString)
+		assert.Equal(t, typ.Format, "regex")
+	})
 	t.Run("ReflectPBEnum_returns_ValidType", func(t *testing.T) {
-		t.Skip("implement")
-		//d := Definitions{}
-		//v := reflect.ValueOf()
-		//
-		//typ := reflectPBEnum(d)
-		//require.NotNil(t, typ)
+		a := assert.New(t)
+		r := require.New(t)
+
+		d := Definitions{}
+		v := reflect.ValueOf(Great)
+
+		typ := reflector.reflectPBEnum(d, v)
+		r.NotNil(typ)
+
+		a.Equal(tTypeInteger, typ.Type)
+		a.Equal([]interface{}{int32(0), int32(1)}, typ.Enum)
+		a.Equal([]*Type{
+			{Title: "Unset", Const: int32(0)},
+			{Title: "Great", Const: int32(1)},
+		}, typ.OneOf)
+	})
+	t.Run("ReflectPBEnum_returns_NameOrNumberWithoutValues", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		d := Definitions{}
+		v := reflect.ValueOf(legacyProtoEnum(0))
+
+		typ := reflector.reflectPBEnum(d, v)
+		r.NotNil(typ)
+
+		a.Empty(typ.Enum)
+		r.Len(typ.OneOf, 2)
+		a.Equal(tTypeString, typ.OneOf[0].Type)
+		a.Equal(tTypeInteger, typ.OneOf[1].Type)
 	})
 	t.Run("ReflectEnum_returns_ValidType", func(t *testing.T) {
 		a := assert.New(t)
@@ -279,7 +348,7 @@ func TestReflect(t *testing.T) {
 
 		v := reflect.ValueOf(enumImpl)
 
-		typ := reflectEnum(d, v)
+		typ := reflector.reflectEnum(d, v)
 		r.NotNil(typ)
 		r.Len(typ.Enum, len(enumVariants))
 		a.Equal(tTypeString, typ.Type)
@@ -300,7 +369,7 @@ func TestReflect(t *testing.T) {
 
 		v := reflect.ValueOf(oneOfImpl)
 
-		typ := reflectOneOf(d, v)
+		typ := reflector.reflectOneOf(d, v)
 		r.NotNil(typ)
 
 		a.Len(typ.OneOf, len(oneOfImplVariants))
@@ -323,7 +392,7 @@ func TestReflect(t *testing.T) {
 
 		v := reflect.ValueOf(anyOfImpl)
 
-		typ := reflectAnyOf(d, v)
+		typ := reflector.reflectAnyOf(d, v)
 		r.NotNil(typ)
 
 		a.Len(typ.AnyOf, len(anyOfImplVariants))
@@ -346,7 +415,7 @@ func TestReflect(t *testing.T) {
 
 		v := reflect.ValueOf(allOfImpl)
 
-		typ := reflectAllOf(d, v)
+		typ := reflector.reflectAllOf(d, v)
 		r.NotNil(typ)
 
 		a.Len(typ.AllOf, len(allOfImplVariants))
@@ -370,7 +439,7 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -384,7 +453,7 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -398,7 +467,7 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -412,7 +481,7 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -426,7 +495,7 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -440,7 +509,7 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -454,7 +523,7 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -462,14 +531,13 @@ func TestReflect(t *testing.T) {
 			a.Equal(typ.Items.Type, tTypeInteger)
 		})
 
-		t.Run("ReflectSlice_returns_ValidTypeOnUint8SLice", func(t *testing.T) {
-			t.Skip("int8 slice handles as []byte. fix it")
+		t.Run("ReflectArray_returns_ValidTypeOnUint8SLice", func(t *testing.T) {
 			d := Definitions{}
 			slice := []uint8{1, 2, 3}
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectArray(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -483,7 +551,7 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -497,7 +565,7 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -511,7 +579,7 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -525,7 +593,7 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -539,7 +607,7 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -553,14 +621,14 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(array)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(tTypeArray, typ.Type)
 			r.NotNil(typ.Items)
 
 			a.Equal(tTypeInteger, typ.Items.Type)
-			a.Equal(4, typ.MaxItems)
-			a.Equal(4, typ.MinItems)
+			a.Equal(intPtr(4), typ.MaxItems)
+			a.Equal(intPtr(4), typ.MinItems)
 		})
 
 		t.Run("ReflectSlice_returns_ValidTypeOnByteSLice", func(t *testing.T) {
@@ -569,25 +637,83 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(array)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 
 			a.Equal(tTypeString, typ.Type)
 			a.Equal("base64", typ.Media.BinaryEncoding)
 		})
 
+		t.Run("ReflectSlice_returns_ValidTypeOnByteArray", func(t *testing.T) {
+			d := Definitions{}
+			var array [32]byte
+
+			v := reflect.ValueOf(array)
+
+			typ := reflector.reflectSlice(d, v)
+			r.NotNil(typ)
+
+			a.Equal(tTypeArray, typ.Type)
+			a.Equal(intPtr(32), typ.MinItems)
+			a.Equal(intPtr(32), typ.MaxItems)
+			r.NotNil(typ.Items)
+			a.Equal(tTypeInteger, typ.Items.Type)
+			a.Nil(typ.Media)
+		})
+
+		t.Run("ReflectSlice_returns_ValidTypeOnTimeSlice", func(t *testing.T) {
+			d := Definitions{}
+			slice := []time.Time{time.Now()}
+
+			v := reflect.ValueOf(slice)
+
+			typ := reflector.reflectSlice(d, v)
+			r.NotNil(typ)
+			a.Equal(tTypeArray, typ.Type)
+			r.NotNil(typ.Items)
+
+			a.Equal(tTypeString, typ.Items.Type)
+			a.Equal("date-time", typ.Items.Format)
+			a.Empty(d)
+		})
+
 		t.Run("ReflectSlice_returns_ValidTypeOnInterfaceSLice", func(t *testing.T) {
 			d := Definitions{}
 			slice := []interface{}{"1", "2", "3"}
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
+			r.NotNil(typ)
+			a.Equal(tTypeArray, typ.Type)
+			r.NotNil(typ.Items)
+
+			a.Equal(tTypeString, typ.Items.Type)
+		})
+
+		t.Run("ReflectSlice_returns_ValidTypeOnHomogeneousInterfaceSLice", func(t *testing.T) {
+			d := Definitions{}
+			slice := []interface{}{1, 2, 3}
+
+			v := reflect.ValueOf(slice)
+
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(tTypeArray, typ.Type)
 			r.NotNil(typ.Items)
 
-			a.Equal(tTypeObject, typ.Items.Type)
+			a.Equal(tTypeInteger, typ.Items.Type)
+			a.Nil(typ.Items.Default)
+		})
+
+		t.Run("ReflectSlice_returns_ValidTypeOnMixedInterfaceSLice", func(t *testing.T) {
+			for _, slice := range [][]interface{}{{1, "2"}, {1, nil}, {}} {
+				typ := reflector.reflectSlice(Definitions{}, reflect.ValueOf(slice))
+				r.NotNil(typ)
+				r.NotNil(typ.Items)
+
+				a.Equal(tTypeObject, typ.Items.Type)
+			}
 		})
 
 		t.Run("ReflectSlice_returns_ValidTypeOnMapSLice", func(t *testing.T) {
@@ -600,7 +726,7 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -614,7 +740,7 @@ func TestReflect(t *testing.T) {
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
@@ -623,18 +749,19 @@ func TestReflect(t *testing.T) {
 		})
 
 		t.Run("ReflectSlice_returns_ValidTypeOnStructSLice", func(t *testing.T) {
-			t.Skip("implement: handle slice of structs")
 			d := Definitions{}
-			slice := []interface{}{}
+			slice := []pageConfig{{}}
 
 			v := reflect.ValueOf(slice)
 
-			typ := reflectSlice(d, v)
+			typ := reflector.reflectSlice(d, v)
 			r.NotNil(typ)
 			a.Equal(typ.Type, tTypeArray)
 			r.NotNil(typ.Items)
 
-			a.Equal(typ.Items.Type, tTypeObject)
+			a.Equal("#/definitions/pageConfig", typ.Items.Ref)
+			r.Contains(d, "pageConfig")
+			a.Equal(tTypeObject, d["pageConfig"].Type)
 		})
 
 	})
@@ -642,17 +769,62 @@ func TestReflect(t *testing.T) {
 		d := Definitions{}
 		v := reflect.ValueOf(map[string]interface{}{})
 
-		typ := reflectMap(d, v)
+		typ := reflector.reflectMap(d, v)
 		require.NotNil(t, typ)
 
 		assert.Equal(t, typ.Type, tTypeObject)
 		assert.Contains(t, typ.PatternProperties, ".*")
 	})
+	t.Run("ReflectMap_returns_ReferenceOnStructPointerMap", func(t *testing.T) {
+		d := Definitions{}
+		v := reflect.ValueOf(map[string]*GrandfatherType{})
+
+		typ := reflector.reflectMap(d, v)
+		require.NotNil(t, typ)
+
+		require.Contains(t, typ.PatternProperties, ".*")
+		assert.Equal(t, "#/definitions/GrandfatherType", typ.PatternProperties[".*"].Ref)
+		require.Contains(t, d, "GrandfatherType")
+		assert.Contains(t, d["GrandfatherType"].Properties, "family_name")
+	})
+	t.Run("ReflectMap_returns_ValueTypeOnPointerMap", func(t *testing.T) {
+		d := Definitions{}
+		v := reflect.ValueOf(map[string]*int{})
+
+		typ := reflector.reflectMap(d, v)
+		require.NotNil(t, typ)
+
+		require.Contains(t, typ.PatternProperties, ".*")
+		assert.Equal(t, tTypeInteger, typ.PatternProperties[".*"].Type)
+	})
+	t.Run("ReflectMap_returns_ValidTypeOnTimeMap", func(t *testing.T) {
+		d := Definitions{}
+		v := reflect.ValueOf(map[string]time.Time{})
+
+		typ := reflector.reflectMap(d, v)
+		require.NotNil(t, typ)
+
+		require.Contains(t, typ.PatternProperties, ".*")
+		assert.Equal(t, tTypeString, typ.PatternProperties[".*"].Type)
+		assert.Equal(t, "date-time", typ.PatternProperties[".*"].Format)
+		assert.Empty(t, d)
+	})
+	t.Run("ReflectMap_returns_FreeFormObjectOnRawMessageMap", func(t *testing.T) {
+		d := Definitions{}
+		v := reflect.ValueOf(map[string]json.RawMessage{})
+
+		typ := reflector.reflectMap(d, v)
+		require.NotNil(t, typ)
+
+		assert.Equal(t, tTypeObject, typ.Type)
+		assert.Equal(t, "true", string(typ.AdditionalProperties))
+		assert.Empty(t, typ.PatternProperties)
+	})
 	t.Run("ReflectInteger_returns_ValidType", func(t *testing.T) {
 		d := Definitions{}
 		v := reflect.ValueOf(int(666))
 
-		typ := reflectInteger(d, v)
+		typ := reflector.reflectInteger(d, v)
 		require.NotNil(t, typ)
 
 		assert.Equal(t, typ.Type, tTypeInteger)
@@ -662,17 +834,31 @@ func TestReflect(t *testing.T) {
 		d := Definitions{}
 		v := reflect.ValueOf(float64(666))
 
-		typ := reflectNumber(d, v)
+		typ := reflector.reflectNumber(d, v)
 		require.NotNil(t, typ)
 
 		assert.Equal(t, typ.Type, tTypeNumber)
 		assert.Equal(t, float64(666), typ.Default)
 	})
+	t.Run("ReflectNumber_returns_ShortestFloat32Default", func(t *testing.T) {
+		d := Definitions{}
+		v := reflect.ValueOf(float32(0.1))
+
+		typ := reflector.reflectNumber(d, v)
+		require.NotNil(t, typ)
+
+		assert.Equal(t, typ.Type, tTypeNumber)
+		assert.Equal(t, 0.1, typ.Default)
+
+		data, err := json.Marshal(typ)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"default":0.1`)
+	})
 	t.Run("ReflectBool_returns_ValidType", func(t *testing.T) {
 		d := Definitions{}
 		v := reflect.ValueOf(float64(666))
 
-		typ := reflectNumber(d, v)
+		typ := reflector.reflectNumber(d, v)
 		require.NotNil(t, typ)
 
 		assert.Equal(t, typ.Type, tTypeNumber)
@@ -682,7 +868,7 @@ func TestReflect(t *testing.T) {
 		d := Definitions{}
 		v := reflect.ValueOf("666")
 
-		typ := reflectString(d, v)
+		typ := reflector.reflectString(d, v)
 		require.NotNil(t, typ)
 
 		assert.Equal(t, typ.Type, tTypeString)
@@ -695,9 +881,2276 @@ func TestReflect(t *testing.T) {
 		vValue := reflect.ValueOf(sValue)
 		vType := reflect.TypeOf(sValue)
 
-		typ := reflectInterface(d, vType, vValue)
+		typ := reflector.reflectInterface(d, vType, vValue)
 		require.NotNil(t, typ)
 
 		assert.Equal(t, typ.Type, tTypeObject)
 	})
 }
+
+type BinaryID [4]byte
+
+func (id BinaryID) MarshalBinary() ([]byte, error) {
+	return id[:], nil
+}
+
+type Fingerprint [4]byte
+
+func (f Fingerprint) MarshalBinary() ([]byte, error) {
+	return f[:], nil
+}
+
+func (f Fingerprint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("%x", f[:]))
+}
+
+type BinaryHolder struct {
+	ID          BinaryID    `json:"id"`
+	IDPtr       *BinaryID   `json:"id_ptr"`
+	Fingerprint Fingerprint `json:"fingerprint"`
+}
+
+func TestReflectBinaryMarshaler(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(BinaryHolder{})
+
+	r.Contains(schema.Properties, "id")
+	idProperty := schema.Properties["id"]
+	a.Equal(tTypeString, idProperty.Type)
+	r.NotNil(idProperty.Media)
+	a.Equal("base64", idProperty.Media.BinaryEncoding)
+
+	r.Contains(schema.Properties, "id_ptr")
+	idPtrProperty := schema.Properties["id_ptr"]
+	a.Equal(tTypeString, idPtrProperty.Type)
+	r.NotNil(idPtrProperty.Media)
+	a.Equal("base64", idPtrProperty.Media.BinaryEncoding)
+
+	// encoding/json calls MarshalJSON, not MarshalBinary
+	r.Contains(schema.Properties, "fingerprint")
+	a.Nil(schema.Properties["fingerprint"].Media)
+}
+
+type Blob []byte
+
+type Attachment struct {
+	Data Blob `json:"data"`
+}
+
+func TestReflectNamedByteSlice(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(Attachment{Data: Blob("hi")})
+
+	r.Contains(schema.Properties, "data")
+	data := schema.Properties["data"]
+	a.Equal(tTypeString, data.Type)
+	a.Nil(data.Items)
+	r.NotNil(data.Media)
+	a.Equal("base64", data.Media.BinaryEncoding)
+}
+
+type RawEnvelope struct {
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func TestReflectRawMessage(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(RawEnvelope{Payload: json.RawMessage(`{"a":1}`)})
+
+	r.Contains(schema.Properties, "payload")
+	payload := schema.Properties["payload"]
+	a.Empty(payload.Type)
+	a.Nil(payload.Media)
+
+	data, err := json.Marshal(payload)
+	r.NoError(err)
+	a.JSONEq(`{}`, string(data))
+}
+
+type Port int
+
+func (Port) Default() interface{} {
+	return 8080
+}
+
+type Listener struct {
+	Host string `json:"host"`
+	Port Port   `json:"port"`
+}
+
+func (Listener) Default() interface{} {
+	return map[string]interface{}{"host": "localhost", "port": 8080}
+}
+
+type Server struct {
+	Listener Listener `json:"listener"`
+	Admin    *Port    `json:"admin"`
+}
+
+func TestReflectDefaulter(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(Server{Listener: Listener{Port: 9090}})
+
+	r.Contains(schema.Definitions, "Listener")
+	a.Equal(8080, schema.Definitions["Listener"].Properties["port"].Default)
+
+	listener := schema.Properties["listener"]
+	a.Equal("#/definitions/Listener", listener.Ref)
+	a.Equal(map[string]interface{}{"host": "localhost", "port": 8080}, listener.Default)
+
+	a.Equal(8080, schema.Properties["admin"].Default)
+}
+
+type YAMLConfig struct {
+	Host    string `yaml:"host" json:"json_host"`
+	Port    int    `yaml:"port"`
+	Skipped string `yaml:"-"`
+}
+
+func TestReflectorPropertyNameTag(t *testing.T) {
+	a := assert.New(t)
+
+	reflector := &Reflector{PropertyNameTag: "yaml"}
+	schema := reflector.Reflect(YAMLConfig{})
+
+	a.Contains(schema.Properties, "host")
+	a.Contains(schema.Properties, "port")
+	a.NotContains(schema.Properties, "json_host")
+	a.Len(schema.Properties, 2)
+
+	schema = Reflect(YAMLConfig{})
+
+	a.Contains(schema.Properties, "json_host")
+	a.Len(schema.Properties, 1)
+}
+
+type RegexpHolder struct {
+	Pattern *regexp.Regexp `json:"pattern"`
+}
+
+func TestReflectRegexp(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(RegexpHolder{Pattern: regexp.MustCompile("^[a-z]+$")})
+
+	r.Contains(schema.Properties, "pattern")
+	patternProperty := schema.Properties["pattern"]
+	a.Equal(tTypeString, patternProperty.Type)
+	a.Equal("regex", patternProperty.Format)
+	a.Empty(schema.Definitions)
+}
+
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	Radius float64 `json:"radius"`
+}
+
+func (c Circle) Area() float64 { return 3.14 * c.Radius * c.Radius }
+
+type Square struct {
+	Side float64 `json:"side"`
+}
+
+func (s *Square) Area() float64 { return s.Side * s.Side }
+
+type Drawing struct {
+	Shape Shape `json:"shape"`
+}
+
+func TestReflectorInterfaceImplementations(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	reflector := &Reflector{
+		InterfaceImplementations: map[reflect.Type][]reflect.Type{
+			reflect.TypeOf((*Shape)(nil)).Elem(): {
+				reflect.TypeOf(Circle{}),
+				reflect.TypeOf(&Square{}),
+			},
+		},
+	}
+
+	schema := reflector.Reflect(Drawing{})
+
+	r.Contains(schema.Properties, "shape")
+	shapeProperty := schema.Properties["shape"]
+	r.Len(shapeProperty.OneOf, 2)
+	a.Equal("#/definitions/Circle", shapeProperty.OneOf[0].Ref)
+	a.Equal("#/definitions/Square", shapeProperty.OneOf[1].Ref)
+
+	r.Contains(schema.Definitions, "Circle")
+	a.Contains(schema.Definitions["Circle"].Properties, "radius")
+	r.Contains(schema.Definitions, "Square")
+	a.Contains(schema.Definitions["Square"].Properties, "side")
+
+	schema = Reflect(Drawing{})
+	a.Empty(schema.Properties["shape"].OneOf)
+}
+
+type Labels struct {
+	Primary   string `json:"primary,omitempty"`
+	Secondary string `json:"secondary,omitempty"`
+	Tertiary  string `json:"tertiary,omitempty"`
+}
+
+func (Labels) MinProperties() int { return 1 }
+
+func (Labels) MaxProperties() int { return 2 }
+
+func TestReflectPropertiesRange(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(Labels{})
+
+	a.Equal(1, schema.MinProperties)
+	a.Equal(2, schema.MaxProperties)
+
+	data, err := json.Marshal(schema)
+	r.NoError(err)
+
+	var keys map[string]interface{}
+	r.NoError(json.Unmarshal(data, &keys))
+	a.Equal(float64(1), keys["minProperties"])
+	a.Equal(float64(2), keys["maxProperties"])
+
+	schema = Reflect(GrandfatherType{})
+
+	data, err = json.Marshal(schema)
+	r.NoError(err)
+
+	keys = nil
+	r.NoError(json.Unmarshal(data, &keys))
+	a.NotContains(keys, "minProperties")
+	a.NotContains(keys, "maxProperties")
+}
+
+type Registry struct {
+	sync.Mutex
+
+	Cache *sync.Map `json:"cache"`
+	Items sync.Map  `json:"items"`
+	Name  string    `json:"name"`
+}
+
+func TestReflectSyncPrimitives(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(&Registry{})
+
+	for _, name := range []string{"cache", "items"} {
+		r.Contains(schema.Properties, name)
+		property := schema.Properties[name]
+		a.Equal(tTypeObject, property.Type)
+		a.Equal("true", string(property.AdditionalProperties))
+		a.Empty(property.Properties)
+		a.Nil(property.Default)
+	}
+
+	a.Len(schema.Properties, 3)
+	a.Empty(schema.Definitions)
+}
+
+type Account struct {
+	GrandfatherType
+
+	Login string `json:"login" jsonschema:"required"`
+	Email string `json:"email,omitempty"`
+}
+
+type PrefixedBase struct {
+	SomeBaseType `jsonschema:"prefix=base_"`
+
+	Name string `json:"name"`
+}
+
+func TestReflectEmbedded(t *testing.T) {
+	t.Run("Reflect_flattens_EmbeddedStruct", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Account{})
+
+		a.Contains(schema.Properties, "family_name")
+		a.Contains(schema.Properties, "login")
+		a.Contains(schema.Properties, "email")
+		a.Empty(schema.AllOf)
+		a.NotContains(schema.Definitions, "GrandfatherType")
+	})
+	t.Run("Reflect_references_EmbeddedStructFromAllOf", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{EmbeddedAsAllOf: true}
+		schema := reflector.Reflect(Account{})
+
+		r.Len(schema.AllOf, 1)
+		a.Equal("#/definitions/GrandfatherType", schema.AllOf[0].Ref)
+		r.Contains(schema.Definitions, "GrandfatherType")
+		a.Contains(schema.Definitions["GrandfatherType"].Properties, "family_name")
+
+		a.Equal(tTypeObject, schema.Type.Type)
+		a.NotContains(schema.Properties, "family_name")
+		a.Contains(schema.Properties, "login")
+		a.Contains(schema.Properties, "email")
+		a.Equal([]string{"login"}, schema.Required)
+	})
+	t.Run("Reflect_prefixes_EmbeddedProperties", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(PrefixedBase{})
+
+		a.Contains(schema.Properties, "base_some_base_property")
+		a.Contains(schema.Properties, "base_grand")
+		a.NotContains(schema.Properties, "some_base_property")
+		a.Contains(schema.Properties, "name")
+	})
+	t.Run("Reflect_merges_EmbeddedRequired", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Account{})
+
+		a.Equal([]string{"family_name", "login"}, schema.Required)
+	})
+	t.Run("Reflect_prefixes_EmbeddedRequired", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{FieldNameCase: NameCasePreserve}
+		schema := reflector.Reflect(PrefixedBase{})
+
+		a.Equal([]string{"base_some_base_property", "base_grand", "base_SomeUntaggedBaseProperty", "name"}, schema.Required)
+	})
+}
+
+func TestReflectRequired(t *testing.T) {
+	a := assert.New(t)
+
+	schema := Reflect(TestUser{})
+
+	a.Equal([]string{"some_base_property", "grand", "id", "name", "photo", "age", "email", "oneOf", "allOf", "anyOf", "enum"}, schema.Required)
+	for _, name := range []string{"i_am_private", "SomeIgnoredBaseProperty", "SomeSchemaIgnoredProperty", "SomeUntaggedBaseProperty"} {
+		a.NotContains(schema.Required, name)
+	}
+}
+
+type Playlist struct {
+	Tracks    []int  `json:"tracks,omitempty"`
+	Favorites *[]int `json:"favorites,omitempty"`
+	Ratings   []int  `json:"ratings"`
+}
+
+func TestReflectorNullableOmitEmptySlices(t *testing.T) {
+	t.Run("Reflect_keeps_SingleTypeByDefault", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := Reflect(Playlist{})
+
+		a.Equal(tTypeArray, schema.Properties["tracks"].Type)
+		a.Empty(schema.Properties["tracks"].Types)
+
+		data, err := json.Marshal(schema.Properties["tracks"])
+		r.NoError(err)
+		a.Contains(string(data), `"type":"array"`)
+	})
+	t.Run("Reflect_allows_NullOnOmitEmptySlices", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{NullableOmitEmptySlices: true}
+		schema := reflector.Reflect(Playlist{})
+
+		a.Equal([]string{tTypeArray, tTypeNull}, schema.Properties["tracks"].Types)
+		a.Equal([]string{tTypeArray, tTypeNull}, schema.Properties["favorites"].Types)
+		a.Empty(schema.Properties["ratings"].Types)
+
+		data, err := json.Marshal(schema)
+		r.NoError(err)
+
+		var decoded struct {
+			Type       string `json:"type"`
+			Properties map[string]struct {
+				Type interface{} `json:"type"`
+			} `json:"properties"`
+		}
+		r.NoError(json.Unmarshal(data, &decoded))
+		a.Equal(tTypeObject, decoded.Type)
+		a.Equal([]interface{}{tTypeArray, tTypeNull}, decoded.Properties["tracks"].Type)
+		a.Equal(tTypeArray, decoded.Properties["ratings"].Type)
+	})
+	t.Run("Reflect_adds_NullOnce", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{NullableOmitEmptySlices: true, Nullable: NullableTypeList}
+		schema := reflector.Reflect(Playlist{})
+
+		a.Equal([]string{tTypeArray, tTypeNull}, schema.Properties["favorites"].Types)
+		a.Equal([]string{tTypeArray, tTypeNull}, schema.Properties["tracks"].Types)
+	})
+}
+
+type Timeouts struct {
+	Read  time.Duration `json:"read"`
+	Write time.Duration `json:"write"`
+}
+
+func TestReflectDuration(t *testing.T) {
+	t.Run("Reflect_returns_IntegerByDefault", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Timeouts{})
+
+		a.Equal(tTypeInteger, schema.Properties["read"].Type)
+		a.Empty(schema.Properties["read"].Format)
+		a.Equal("nanoseconds", schema.Properties["read"].Description)
+	})
+	t.Run("Reflect_returns_ISODurationString", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{DurationAsString: true}
+		schema := reflector.Reflect(Timeouts{Read: 90 * time.Minute, Write: 1500 * time.Millisecond})
+
+		a.Equal(tTypeString, schema.Properties["read"].Type)
+		a.Equal("duration", schema.Properties["read"].Format)
+		a.Equal("PT1H30M", schema.Properties["read"].Default)
+		a.Empty(schema.Properties["read"].Description)
+		a.Equal("PT1.5S", schema.Properties["write"].Default)
+	})
+	t.Run("FormatISODuration", func(t *testing.T) {
+		a := assert.New(t)
+
+		a.Equal("PT0S", formatISODuration(0))
+		a.Equal("PT2H", formatISODuration(2*time.Hour))
+		a.Equal("PT1M5S", formatISODuration(time.Minute+5*time.Second))
+		a.Equal("-PT10S", formatISODuration(-10*time.Second))
+	})
+}
+
+type Checkpoint struct {
+	At time.Time `json:"at"`
+}
+
+func TestReflectTimestampInteger(t *testing.T) {
+	a := assert.New(t)
+
+	reflector := &Reflector{TimestampInteger: true}
+	schema := reflector.Reflect(Checkpoint{At: time.Date(2019, 5, 1, 12, 30, 0, 0, time.UTC)})
+
+	at := schema.Properties["at"]
+	a.Equal(tTypeInteger, at.Type)
+	a.Equal("unix-time", at.Format)
+	a.Equal(int64(1556713800), at.Default)
+}
+
+func TestReflectorClone(t *testing.T) {
+	a := assert.New(t)
+
+	shape := reflect.TypeOf((*Shape)(nil)).Elem()
+
+	original := &Reflector{
+		PropertyNameTag: "yaml",
+		InterfaceImplementations: map[reflect.Type][]reflect.Type{
+			shape: {reflect.TypeOf(Circle{})},
+		},
+	}
+
+	clone := original.Clone()
+	clone.PropertyNameTag = "json"
+	clone.DurationAsString = true
+	clone.InterfaceImplementations[shape][0] = reflect.TypeOf(&Square{})
+	clone.InterfaceImplementations[shape] = append(clone.InterfaceImplementations[shape], reflect.TypeOf(Circle{}))
+
+	a.Equal("yaml", original.PropertyNameTag)
+	a.False(original.DurationAsString)
+	a.Equal([]reflect.Type{reflect.TypeOf(Circle{})}, original.InterfaceImplementations[shape])
+
+	a.Equal("json", clone.PropertyNameTag)
+	a.Len(clone.InterfaceImplementations[shape], 2)
+}
+
+type Ledger struct {
+	ID      int64   `json:"id"`
+	Serial  uint64  `json:"serial"`
+	Balance float64 `json:"balance"`
+	Count   int32   `json:"count"`
+}
+
+func TestReflectorNumberAsString(t *testing.T) {
+	a := assert.New(t)
+
+	reflector := &Reflector{NumberAsString: true}
+	schema := reflector.Reflect(Ledger{ID: -9007199254740993, Serial: 18446744073709551615, Balance: 1.5})
+
+	idProperty := schema.Properties["id"]
+	a.Equal(tTypeString, idProperty.Type)
+	a.Equal(patternInteger, idProperty.Pattern)
+	a.Equal("-9007199254740993", idProperty.Default)
+	a.Regexp(idProperty.Pattern, idProperty.Default)
+
+	serialProperty := schema.Properties["serial"]
+	a.Equal(tTypeString, serialProperty.Type)
+	a.Equal("18446744073709551615", serialProperty.Default)
+	a.Regexp(serialProperty.Pattern, serialProperty.Default)
+
+	balanceProperty := schema.Properties["balance"]
+	a.Equal(tTypeString, balanceProperty.Type)
+	a.Equal("1.5", balanceProperty.Default)
+	a.Regexp(balanceProperty.Pattern, balanceProperty.Default)
+
+	a.Equal(tTypeInteger, schema.Properties["count"].Type)
+
+	schema = Reflect(Ledger{})
+	a.Equal(tTypeInteger, schema.Properties["id"].Type)
+	a.Equal(tTypeNumber, schema.Properties["balance"].Type)
+}
+
+type Measurement struct {
+	Ratio float32 `json:"ratio"`
+	Value float64 `json:"value"`
+	Count int32   `json:"count"`
+	Total int64   `json:"total"`
+	Index int     `json:"index"`
+}
+
+func TestReflectorEmitNumberFormats(t *testing.T) {
+	a := assert.New(t)
+
+	schema := Reflect(Measurement{})
+	a.Empty(schema.Properties["ratio"].Format)
+	a.Empty(schema.Properties["value"].Format)
+	a.Empty(schema.Properties["total"].Format)
+
+	reflector := &Reflector{EmitNumberFormats: true}
+	schema = reflector.Reflect(Measurement{})
+
+	a.Equal(tTypeNumber, schema.Properties["ratio"].Type)
+	a.Equal("float", schema.Properties["ratio"].Format)
+	a.Equal(tTypeNumber, schema.Properties["value"].Type)
+	a.Equal("double", schema.Properties["value"].Format)
+	a.Equal(tTypeInteger, schema.Properties["count"].Type)
+	a.Equal("int32", schema.Properties["count"].Format)
+	a.Equal(tTypeInteger, schema.Properties["total"].Type)
+	a.Equal("int64", schema.Properties["total"].Format)
+	a.Empty(schema.Properties["index"].Format)
+}
+
+type Checksum struct {
+	SHA256 [32]byte `json:"sha256" jsonschema:"binary=true"`
+	Nonce  [8]byte  `json:"nonce"`
+	Parts  [3]int   `json:"parts"`
+}
+
+func TestReflectFixedArrays(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(Checksum{})
+
+	r.Contains(schema.Properties, "sha256")
+	a.Equal(tTypeString, schema.Properties["sha256"].Type)
+	a.Equal("byte", schema.Properties["sha256"].Format)
+	a.Equal(intPtr(44), schema.Properties["sha256"].MinLength)
+	a.Equal(intPtr(44), schema.Properties["sha256"].MaxLength)
+
+	r.Contains(schema.Properties, "nonce")
+	a.Equal(tTypeArray, schema.Properties["nonce"].Type)
+	a.Equal(intPtr(8), schema.Properties["nonce"].MinItems)
+	a.Equal(intPtr(8), schema.Properties["nonce"].MaxItems)
+
+	r.Contains(schema.Properties, "parts")
+	a.Equal(tTypeArray, schema.Properties["parts"].Type)
+	a.Equal(intPtr(3), schema.Properties["parts"].MinItems)
+	a.Equal(intPtr(3), schema.Properties["parts"].MaxItems)
+}
+
+type Parent struct {
+	Father GrandfatherType `json:"father"`
+}
+
+type Uncle struct {
+	Grandfather GrandfatherType `json:"grandfather"`
+}
+
+func TestReflectorReflectInto(t *testing.T) {
+	t.Run("ReflectInto_shares_Definitions", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{}
+		bundle := Definitions{}
+
+		parent, err := reflector.ReflectInto(bundle, Parent{})
+		r.NoError(err)
+		uncle, err := reflector.ReflectInto(bundle, Uncle{})
+		r.NoError(err)
+
+		a.Len(bundle, 1)
+		a.Contains(bundle, "GrandfatherType")
+		a.Equal("#/definitions/GrandfatherType", parent.Properties["father"].Ref)
+		a.Equal("#/definitions/GrandfatherType", uncle.Properties["grandfather"].Ref)
+		a.Empty(parent.Version)
+	})
+	t.Run("ReflectInto_fails_OnConflictingDefinitions", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{}
+		bundle := Definitions{
+			"GrandfatherType": Reflect(SomeStruct{}).Type,
+		}
+
+		typ, err := reflector.ReflectInto(bundle, Parent{})
+		r.Error(err)
+		a.Contains(err.Error(), "GrandfatherType")
+		a.Nil(typ)
+		a.Len(bundle, 1)
+	})
+}
+
+var (
+	pointerVariantLow  = "low"
+	pointerVariantHigh = "high"
+)
+
+type pointerEnum string
+
+func (pointerEnum) Enum() []interface{} {
+	return []interface{}{&pointerVariantLow, &pointerVariantHigh}
+}
+
+type pointerOneOf struct{}
+
+func (pointerOneOf) OneOf() []interface{} {
+	return []interface{}{&pointerVariantLow, &GrandfatherType{}}
+}
+
+func TestReflectPointerVariants(t *testing.T) {
+	reflector := &Reflector{}
+
+	t.Run("ReflectEnum_dereferences_PointerVariants", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		typ := reflector.reflectEnum(Definitions{}, reflect.ValueOf(pointerEnum("")))
+		r.NotNil(typ)
+
+		a.Equal(tTypeString, typ.Type)
+		a.Equal([]interface{}{"low", "high"}, typ.Enum)
+	})
+	t.Run("ReflectOneOf_dereferences_PointerVariants", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		d := Definitions{}
+
+		typ := reflector.reflectOneOf(d, reflect.ValueOf(pointerOneOf{}))
+		r.NotNil(typ)
+		r.Len(typ.OneOf, 2)
+
+		a.Equal(tTypeString, typ.OneOf[0].Type)
+		a.Equal("low", typ.OneOf[0].Default)
+		a.Equal("#/definitions/GrandfatherType", typ.OneOf[1].Ref)
+		a.Contains(d, "GrandfatherType")
+	})
+}
+
+type Corner struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func (Corner) Enum() []interface{} {
+	return []interface{}{Corner{X: 0, Y: 0}, &Corner{X: 1, Y: 1}}
+}
+
+type CornerPicker struct {
+	Start Corner `json:"start"`
+}
+
+func TestReflectStructEnum(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := (&Reflector{}).Reflect(&CornerPicker{})
+
+	start := schema.Properties["start"]
+	r.NotNil(start)
+
+	a.Equal(tTypeObject, start.Type)
+	a.Equal([]interface{}{
+		map[string]interface{}{"x": float64(0), "y": float64(0)},
+		map[string]interface{}{"x": float64(1), "y": float64(1)},
+	}, start.Enum)
+	a.NotContains(schema.Definitions, "Corner")
+
+	data, err := json.Marshal(start)
+	r.NoError(err)
+	a.Contains(string(data), `"enum":[{"x":0,"y":0},{"x":1,"y":1}]`)
+}
+
+type Money struct {
+	Units int64 `json:"units"`
+	Nanos int32 `json:"nanos"`
+}
+
+func (Money) JSONSchema() *Type {
+	return &Type{
+		Type:    tTypeString,
+		Pattern: `^-?[0-9]+(\.[0-9]+)?$`,
+	}
+}
+
+type Invoice struct {
+	Total Money `json:"total"`
+}
+
+var currencySchema = &Type{Type: tTypeString, Pattern: "^[A-Z]{3}$"}
+
+type Currency string
+
+func (Currency) JSONSchema() *Type {
+	return currencySchema
+}
+
+type Unschemed struct {
+	Code string `json:"code"`
+}
+
+func (Unschemed) JSONSchema() *Type {
+	return nil
+}
+
+type Exchange struct {
+	Base    Currency  `json:"base" jsonschema:"description=base currency"`
+	Quote   Currency  `json:"quote" jsonschema:"description=quote currency"`
+	Fee     Currency  `json:"fee" jsonschema:"description=fee currency"`
+	Details Unschemed `json:"details"`
+}
+
+func TestReflectorUseJSONSchemaInterface(t *testing.T) {
+	t.Run("Reflect_uses_JSONSchemaHook", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{UseJSONSchemaInterface: true}
+		schema := reflector.Reflect(Invoice{})
+
+		r.Contains(schema.Properties, "total")
+		a.Equal(tTypeString, schema.Properties["total"].Type)
+		a.Equal(Money{}.JSONSchema().Pattern, schema.Properties["total"].Pattern)
+		a.NotContains(schema.Definitions, "Money")
+	})
+	t.Run("Reflect_ignores_JSONSchemaHookWhenDisabled", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := Reflect(Invoice{})
+
+		r.Contains(schema.Properties, "total")
+		a.Equal("#/definitions/Money", schema.Properties["total"].Ref)
+		r.Contains(schema.Definitions, "Money")
+		a.Contains(schema.Definitions["Money"].Properties, "units")
+		a.Contains(schema.Definitions["Money"].Properties, "nanos")
+	})
+	t.Run("Reflect_copies_ProvidedSchemas", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{UseJSONSchemaInterface: true}
+		schema := reflector.Reflect(Exchange{})
+
+		a.Equal("base currency", schema.Properties["base"].Description)
+		a.Equal("quote currency", schema.Properties["quote"].Description)
+		a.Equal("fee currency", schema.Properties["fee"].Description)
+		a.Empty(currencySchema.Description)
+	})
+	t.Run("Reflect_reflects_NilProvidedSchemas", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{UseJSONSchemaInterface: true}
+		schema := reflector.Reflect(Exchange{})
+
+		a.Equal("#/definitions/Unschemed", schema.Properties["details"].Ref)
+		a.Contains(schema.Definitions["Unschemed"].Properties, "code")
+	})
+}
+
+type contextImpl struct {
+	context.Context
+}
+
+type Request struct {
+	context.Context
+
+	Ctx     context.Context `json:"ctx"`
+	Wrapped *contextImpl    `json:"wrapped"`
+	Query   string          `json:"query"`
+}
+
+func TestReflectSkipsContext(t *testing.T) {
+	a := assert.New(t)
+
+	schema := Reflect(Request{Ctx: context.Background()})
+
+	a.NotContains(schema.Properties, "ctx")
+	a.NotContains(schema.Properties, "wrapped")
+	a.Contains(schema.Properties, "query")
+	a.Len(schema.Properties, 1)
+	a.Empty(schema.Definitions)
+}
+
+type Household struct {
+	Head  GrandfatherType `json:"head" jsonschema:"title=Head of household"`
+	Other GrandfatherType `json:"other"`
+	Guest GrandfatherType `json:"guest" jsonschema:"comment=Visits on weekends,examples=Bob|Alice"`
+}
+
+type Tenancy struct {
+	Owner  GrandfatherType `json:"owner" jsonschema:"readOnly=true"`
+	Tenant GrandfatherType `json:"tenant" jsonschema:"default=Doe"`
+}
+
+func TestReflectorWrapRefAnnotations(t *testing.T) {
+	t.Run("Reflect_keeps_TitleAlongsideRef", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Household{})
+
+		headProperty := schema.Properties["head"]
+		a.Equal("#/definitions/GrandfatherType", headProperty.Ref)
+		a.Equal("Head of household", headProperty.Title)
+		a.Empty(headProperty.AllOf)
+
+		guestProperty := schema.Properties["guest"]
+		a.Equal("#/definitions/GrandfatherType", guestProperty.Ref)
+		a.Equal("Visits on weekends", guestProperty.Comment)
+		a.Equal([]interface{}{"Bob", "Alice"}, guestProperty.Examples)
+	})
+	t.Run("Reflect_wraps_AnnotatedRefInAllOf", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{WrapRefAnnotations: true}
+		schema := reflector.Reflect(Household{})
+
+		headProperty := schema.Properties["head"]
+		a.Empty(headProperty.Ref)
+		a.Equal("Head of household", headProperty.Title)
+		r.Len(headProperty.AllOf, 1)
+		a.Equal("#/definitions/GrandfatherType", headProperty.AllOf[0].Ref)
+		a.Empty(headProperty.AllOf[0].Title)
+
+		otherProperty := schema.Properties["other"]
+		a.Equal("#/definitions/GrandfatherType", otherProperty.Ref)
+		a.Empty(otherProperty.AllOf)
+
+		guestProperty := schema.Properties["guest"]
+		a.Empty(guestProperty.Ref)
+		a.Equal("Visits on weekends", guestProperty.Comment)
+		a.Equal([]interface{}{"Bob", "Alice"}, guestProperty.Examples)
+		r.Len(guestProperty.AllOf, 1)
+		a.Equal("#/definitions/GrandfatherType", guestProperty.AllOf[0].Ref)
+		a.Empty(guestProperty.AllOf[0].Comment)
+
+		a.Equal([]string{"GrandfatherType"}, schema.UsedDefinitions())
+	})
+	t.Run("Reflect_wraps_EveryKeywordNextToRef", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{WrapRefAnnotations: true}
+		schema := reflector.Reflect(Tenancy{})
+
+		owner := schema.Properties["owner"]
+		a.Empty(owner.Ref)
+		a.True(owner.ReadOnly)
+		r.Len(owner.AllOf, 1)
+		a.Equal(&Type{Ref: "#/definitions/GrandfatherType"}, owner.AllOf[0])
+
+		tenant := schema.Properties["tenant"]
+		a.Empty(tenant.Ref)
+		a.Equal("Doe", tenant.Default)
+		r.Len(tenant.AllOf, 1)
+		a.Equal(&Type{Ref: "#/definitions/GrandfatherType"}, tenant.AllOf[0])
+	})
+}
+
+type Clan struct {
+	Elders    []GrandfatherType  `json:"elders"`
+	Ancestors []GrandfatherType  `json:"ancestors"`
+	Founders  []*GrandfatherType `json:"founders"`
+}
+
+func TestReflectSharedSliceElements(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(Clan{})
+
+	for _, name := range []string{"elders", "ancestors", "founders"} {
+		r.Contains(schema.Properties, name)
+		property := schema.Properties[name]
+		a.Equal(tTypeArray, property.Type)
+		r.NotNil(property.Items)
+		a.Equal("#/definitions/GrandfatherType", property.Items.Ref)
+	}
+
+	a.Len(schema.Definitions, 1)
+	a.Contains(schema.Definitions, "GrandfatherType")
+}
+
+func TestReflectorOnType(t *testing.T) {
+	a := assert.New(t)
+
+	var reflected []reflect.Type
+
+	reflector := &Reflector{
+		OnType: func(t reflect.Type, typ *Type) {
+			reflected = append(reflected, t)
+			typ.Description = "reflected from " + t.String()
+		},
+	}
+
+	schema := reflector.Reflect(Ledger{})
+
+	a.Equal([]reflect.Type{
+		reflect.TypeOf(int64(0)),
+		reflect.TypeOf(uint64(0)),
+		reflect.TypeOf(float64(0)),
+		reflect.TypeOf(int32(0)),
+		reflect.TypeOf(Ledger{}),
+	}, reflected)
+
+	a.Equal("reflected from int64", schema.Properties["id"].Description)
+	a.Equal("reflected from int32", schema.Properties["count"].Description)
+	a.Equal("reflected from jsonschema.Ledger", schema.Description)
+}
+
+type Event interface {
+	EventKind() string
+}
+
+type Created struct {
+	Kind string `json:"kind" jsonschema:"const=created"`
+	ID   int    `json:"id"`
+}
+
+func (Created) EventKind() string { return "created" }
+
+type Deleted struct {
+	Kind   string `json:"kind" jsonschema:"const=deleted"`
+	Reason string `json:"reason"`
+}
+
+func (Deleted) EventKind() string { return "deleted" }
+
+type Envelope struct {
+	Event Event `json:"event"`
+}
+
+func TestReflectorDiscriminatedUnion(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	event := reflect.TypeOf((*Event)(nil)).Elem()
+
+	reflector := &Reflector{
+		InterfaceImplementations: map[reflect.Type][]reflect.Type{
+			event: {reflect.TypeOf(Created{}), reflect.TypeOf(Deleted{})},
+		},
+		Discriminators: map[reflect.Type]string{
+			event: "kind",
+		},
+	}
+
+	schema := reflector.Reflect(Envelope{})
+
+	eventProperty := schema.JSONPointer("properties", "event")
+	r.NotNil(eventProperty)
+	r.Len(eventProperty.OneOf, 2)
+	a.Equal("#/definitions/Created", eventProperty.OneOf[0].Ref)
+	a.Equal("#/definitions/Deleted", eventProperty.OneOf[1].Ref)
+
+	r.NotNil(eventProperty.Discriminator)
+	a.Equal("kind", eventProperty.Discriminator.PropertyName)
+	a.Equal(map[string]string{
+		"created": "#/definitions/Created",
+		"deleted": "#/definitions/Deleted",
+	}, eventProperty.Discriminator.Mapping)
+
+	created := schema.JSONPointer("definitions", "Created")
+	r.NotNil(created)
+	a.Equal("created", created.Properties["kind"].Const)
+	a.Contains(created.Required, "kind")
+
+	deleted := schema.JSONPointer("definitions", "Deleted")
+	r.NotNil(deleted)
+	a.Equal("deleted", deleted.Properties["kind"].Const)
+	a.Contains(deleted.Required, "kind")
+
+	data, err := json.Marshal(schema)
+	r.NoError(err)
+	a.Contains(string(data), `"discriminator":{"propertyName":"kind","mapping":{"created":"#/definitions/Created","deleted":"#/definitions/Deleted"}}`)
+}
+
+type Widget interface {
+	WidgetKind() string
+}
+
+type Label struct {
+	Kind string `json:"kind" jsonschema:"const=label"`
+	Text string `json:"text"`
+}
+
+func (Label) WidgetKind() string { return "label" }
+
+type Panel struct {
+	Kind     string   `json:"kind" jsonschema:"const=panel"`
+	Children []Widget `json:"children"`
+}
+
+func (Panel) WidgetKind() string { return "panel" }
+
+type Window struct {
+	Root Widget `json:"root"`
+}
+
+func TestReflectorDiscriminatorsRecursive(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	widget := reflect.TypeOf((*Widget)(nil)).Elem()
+	reflector := &Reflector{
+		InterfaceImplementations: map[reflect.Type][]reflect.Type{
+			widget: {reflect.TypeOf(Label{}), reflect.TypeOf(Panel{})},
+		},
+		Discriminators: map[reflect.Type]string{
+			widget: "kind",
+		},
+	}
+
+	schema := reflector.Reflect(Window{})
+
+	mapping := map[string]string{
+		"label": "#/definitions/Label",
+		"panel": "#/definitions/Panel",
+	}
+
+	root := schema.Properties["root"]
+	r.NotNil(root.Discriminator)
+	a.Equal(mapping, root.Discriminator.Mapping)
+
+	children := schema.JSONPointer("definitions", "Panel", "properties", "children", "items")
+	r.NotNil(children)
+	r.NotNil(children.Discriminator)
+	a.Equal(mapping, children.Discriminator.Mapping)
+
+	a.Contains(schema.Definitions["Label"].Required, "kind")
+	a.Contains(schema.Definitions["Panel"].Required, "kind")
+
+	a.Equal(&Type{}, definitionInProgress)
+	a.Equal(&Type{}, definitionReferenced)
+}
+
+type Untagged struct {
+	HostName string
+	Port     int    `json:",omitempty"`
+	Tagged   string `json:"tagged"`
+	Ignored  string `json:"-"`
+}
+
+func TestReflectorFieldNameCase(t *testing.T) {
+	t.Run("Reflect_skips_UntaggedFieldsByDefault", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Untagged{})
+
+		a.Len(schema.Properties, 1)
+		a.Contains(schema.Properties, "tagged")
+	})
+	t.Run("Reflect_preserves_FieldNameCase", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{FieldNameCase: NameCasePreserve}
+		schema := reflector.Reflect(Untagged{})
+
+		a.Len(schema.Properties, 3)
+		a.Contains(schema.Properties, "HostName")
+		a.Contains(schema.Properties, "Port")
+		a.Contains(schema.Properties, "tagged")
+	})
+	t.Run("Reflect_lowercases_FieldNames", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{FieldNameCase: NameCaseLower}
+		schema := reflector.Reflect(Untagged{})
+
+		a.Len(schema.Properties, 3)
+		a.Contains(schema.Properties, "hostname")
+		a.Contains(schema.Properties, "port")
+		a.Contains(schema.Properties, "tagged")
+	})
+}
+
+type Marker struct {
+	Empty  struct{} `json:"empty"`
+	Inline struct {
+		Name string `json:"name"`
+	} `json:"inline"`
+}
+
+func TestReflectAnonymousStructs(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(Marker{})
+
+	a.Empty(schema.Definitions)
+
+	emptyProperty := schema.Properties["empty"]
+	a.Equal(tTypeObject, emptyProperty.Type)
+	a.Empty(emptyProperty.Ref)
+	a.Empty(emptyProperty.Properties)
+
+	data, err := json.Marshal(emptyProperty)
+	r.NoError(err)
+	a.JSONEq(`{"type":"object"}`, string(data))
+
+	inlineProperty := schema.Properties["inline"]
+	a.Equal(tTypeObject, inlineProperty.Type)
+	a.Contains(inlineProperty.Properties, "name")
+}
+
+type HTTPServerConfig struct {
+	Upstream GrandfatherType `json:"upstream"`
+}
+
+type Gateway struct {
+	Server HTTPServerConfig `json:"server"`
+}
+
+func TestReflectorDefinitionNameFromJSONTag(t *testing.T) {
+	t.Run("Reflect_keys_DefinitionsByTypeName", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Gateway{})
+
+		a.Equal([]string{"GrandfatherType", "HTTPServerConfig"}, schema.UsedDefinitions())
+	})
+	t.Run("Reflect_keys_DefinitionsBySnakeCasedTypeName", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{DefinitionNameFromJSONTag: true}
+		schema := reflector.Reflect(Gateway{})
+
+		r.Contains(schema.Definitions, "http_server_config")
+		r.Contains(schema.Definitions, "grandfather_type")
+		a.Len(schema.Definitions, 2)
+		a.Equal("#/definitions/http_server_config", schema.Properties["server"].Ref)
+		a.Equal("#/definitions/grandfather_type", schema.Definitions["http_server_config"].Properties["upstream"].Ref)
+	})
+	t.Run("snakeCase_splits_Words", func(t *testing.T) {
+		a := assert.New(t)
+
+		a.Equal("user", snakeCase("User"))
+		a.Equal("user_profile", snakeCase("UserProfile"))
+		a.Equal("user_id", snakeCase("UserID"))
+		a.Equal("http_server", snakeCase("HTTPServer"))
+		a.Equal("v2_config", snakeCase("V2Config"))
+	})
+}
+
+type HeaderValues map[string]string
+
+type Headers struct {
+	HeaderValues `jsonschema:"propertyNames=^[A-Z][A-Za-z-]*$"`
+}
+
+type Routing struct {
+	Weights map[string]int `json:"weights" jsonschema:"propertyNames=^[a-z]+$"`
+}
+
+func TestReflectPropertyNames(t *testing.T) {
+	t.Run("Reflect_names_EmbeddedMapAfterItsType", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{FieldNameCase: NameCasePreserve}
+		schema := reflector.Reflect(Headers{})
+
+		a.Equal(tTypeObject, schema.Type.Type)
+		a.Empty(schema.PatternProperties)
+		r.Contains(schema.Properties, "HeaderValues")
+
+		values := schema.Properties["HeaderValues"]
+		a.Equal(tTypeObject, values.Type)
+		r.Contains(values.PatternProperties, ".*")
+		a.Equal(tTypeString, values.PatternProperties[".*"].Type)
+		r.NotNil(values.PropertyNames)
+		a.Equal("^[A-Z][A-Za-z-]*$", values.PropertyNames.Pattern)
+	})
+	t.Run("Reflect_constrains_MapFieldKeys", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := Reflect(Routing{})
+
+		weights := schema.Properties["weights"]
+		r.NotNil(weights.PropertyNames)
+		a.Equal("^[a-z]+$", weights.PropertyNames.Pattern)
+	})
+}
+
+type Order struct {
+	ID string `json:"id"`
+}
+
+func (Order) JSONSchemaLinks() []Link {
+	return []Link{
+		{Rel: "self", Href: "/orders/{id}"},
+		{Rel: "collection", Href: "/orders"},
+	}
+}
+
+type Customer struct {
+	LastOrder Order `json:"last_order"`
+}
+
+func TestReflectLinks(t *testing.T) {
+	t.Run("Reflect_emits_StructLinks", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Order{})
+
+		a.Equal([]Link{
+			{Rel: "self", Href: "/orders/{id}"},
+			{Rel: "collection", Href: "/orders"},
+		}, schema.Links)
+
+		data, err := json.Marshal(schema)
+		a.NoError(err)
+		a.Contains(string(data), `"links":[{"rel":"self","href":"/orders/{id}"},{"rel":"collection","href":"/orders"}]`)
+	})
+	t.Run("Reflect_keeps_LinksOnDefinition", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := Reflect(Customer{})
+
+		a.Empty(schema.Links)
+		r.Contains(schema.Definitions, "Order")
+		a.Len(schema.Definitions["Order"].Links, 2)
+	})
+}
+
+type TreeNode struct {
+	Value    int        `json:"value"`
+	Children []TreeNode `json:"children"`
+	Parent   *TreeNode  `json:"parent,omitempty"`
+}
+
+type Forest struct {
+	Trees []TreeNode `json:"trees"`
+}
+
+func TestReflectRecursive(t *testing.T) {
+	t.Run("Reflect_references_SelfFromRoot", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := Reflect(TreeNode{})
+
+		r.NotNil(schema.Properties["children"].Items)
+		a.Equal("#/definitions/TreeNode", schema.Properties["children"].Items.Ref)
+		a.Equal("#/definitions/TreeNode", schema.Properties["parent"].Ref)
+
+		r.Contains(schema.Definitions, "TreeNode")
+		node := schema.Definitions["TreeNode"]
+		a.Contains(node.Properties, "value")
+		a.Equal("#/definitions/TreeNode", node.Properties["children"].Items.Ref)
+		a.Equal("#/definitions/TreeNode", node.Properties["parent"].Ref)
+	})
+	t.Run("Reflect_references_SelfFromDefinition", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := Reflect(Forest{})
+
+		a.Equal("#/definitions/TreeNode", schema.Properties["trees"].Items.Ref)
+		r.Contains(schema.Definitions, "TreeNode")
+		a.Equal("#/definitions/TreeNode", schema.Definitions["TreeNode"].Properties["children"].Items.Ref)
+		a.Len(schema.Definitions, 1)
+
+		_, err := json.Marshal(schema)
+		a.NoError(err)
+	})
+}
+
+type Coordinate struct {
+	X, Y int
+}
+
+func (c Coordinate) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d,%d", c.X, c.Y)), nil
+}
+
+func (c Coordinate) String() string {
+	return fmt.Sprintf("(%d, %d)", c.X, c.Y)
+}
+
+type Board struct {
+	Cells map[Coordinate]string `json:"cells"`
+}
+
+func TestReflectMapKeys(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(Board{Cells: map[Coordinate]string{{X: 1, Y: 2}: "x"}})
+
+	cells := schema.Properties["cells"]
+	a.Equal(tTypeObject, cells.Type)
+	r.Contains(cells.PatternProperties, ".*")
+	a.Equal(tTypeString, cells.PatternProperties[".*"].Type)
+	a.Empty(schema.Definitions)
+
+	_, err := json.Marshal(schema)
+	a.NoError(err)
+}
+
+type Roster struct {
+	Friends  []int    `json:"friends" jsonschema:"required"`
+	Rivals   []int    `json:"rivals,omitempty"`
+	Partners []string `json:"partners" jsonschema:"required,minItems=2"`
+	Pair     [2]int   `json:"pair" jsonschema:"required"`
+}
+
+func TestReflectorMinLengthForRequiredArrays(t *testing.T) {
+	t.Run("Reflect_leaves_MinItemsUnsetByDefault", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Roster{})
+
+		a.Nil(schema.Properties["friends"].MinItems)
+	})
+	t.Run("Reflect_requires_OneItemInRequiredArrays", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{MinLengthForRequiredArrays: true}
+		schema := reflector.Reflect(Roster{})
+
+		a.Equal(intPtr(1), schema.Properties["friends"].MinItems)
+		a.Nil(schema.Properties["rivals"].MinItems)
+		a.Equal(intPtr(2), schema.Properties["partners"].MinItems)
+		a.Equal(intPtr(2), schema.Properties["pair"].MinItems)
+	})
+}
+
+func TestReflectorOptions(t *testing.T) {
+	t.Run("Reflect_references_NestedStructsByDefault", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Household{})
+
+		a.Equal("#/definitions/GrandfatherType", schema.Properties["other"].Ref)
+		a.Contains(schema.Definitions, "GrandfatherType")
+	})
+	t.Run("Reflect_inlines_NestedStructsWhenExpanded", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{ExpandStructs: true}
+		schema := reflector.Reflect(Household{})
+
+		other := schema.Properties["other"]
+		a.Empty(other.Ref)
+		a.Equal(tTypeObject, other.Type)
+		r.Contains(other.Properties, "family_name")
+		a.Equal([]string{"family_name"}, other.Required)
+		a.Empty(schema.Definitions)
+	})
+	t.Run("Reflect_inlines_DefinitionsWithoutReference", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{DoNotReference: true}
+		schema := reflector.Reflect(Household{})
+
+		head := schema.Properties["head"]
+		a.Empty(head.Ref)
+		a.Equal(tTypeObject, head.Type)
+		r.Contains(head.Properties, "family_name")
+		a.Equal("Head of household", head.Title)
+
+		a.NotContains(schema.Definitions, "GrandfatherType")
+		a.Empty(schema.Properties["other"].Title)
+	})
+	t.Run("Reflect_keeps_RecursiveDefinitionsWithoutReference", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{DoNotReference: true}
+		schema := reflector.Reflect(Forest{})
+
+		trees := schema.Properties["trees"]
+		r.NotNil(trees.Items)
+		a.Empty(trees.Items.Ref)
+		a.Equal("#/definitions/TreeNode", trees.Items.Properties["children"].Items.Ref)
+		a.Equal([]string{"TreeNode"}, schema.UsedDefinitions())
+		a.Len(schema.Definitions, 1)
+	})
+	t.Run("Reflect_requires_OmitEmptyFieldsByDefault", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{RequiredByDefault: true}
+		schema := reflector.Reflect(Account{})
+
+		a.Equal([]string{"family_name", "login", "email"}, schema.Required)
+	})
+}
+
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusInactive Status = "inactive"
+)
+
+type Subscription struct {
+	Status  Status  `json:"status"`
+	Pending *Status `json:"pending,omitempty"`
+	Tier    int     `json:"tier"`
+}
+
+func TestReflectorRegisterEnum(t *testing.T) {
+	t.Run("Reflect_ignores_UnregisteredTypes", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Subscription{})
+
+		a.Equal(tTypeString, schema.Properties["status"].Type)
+		a.Empty(schema.Properties["status"].Enum)
+	})
+	t.Run("Reflect_emits_RegisteredValues", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{}
+		reflector.RegisterEnum(reflect.TypeOf(Status("")), StatusActive, StatusInactive)
+		schema := reflector.Reflect(Subscription{})
+
+		for _, name := range []string{"status", "pending"} {
+			a.Equal(tTypeString, schema.Properties[name].Type, name)
+			a.Equal([]interface{}{StatusActive, StatusInactive}, schema.Properties[name].Enum, name)
+		}
+		a.Empty(schema.Properties["tier"].Enum)
+
+		data, err := json.Marshal(schema.Properties["status"])
+		a.NoError(err)
+		a.Contains(string(data), `"enum":["active","inactive"]`)
+	})
+	t.Run("Clone_copies_RegisteredValues", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{}
+		reflector.RegisterEnum(reflect.TypeOf(Status("")), StatusActive)
+		clone := reflector.Clone()
+		clone.RegisterEnum(reflect.TypeOf(Status("")), StatusInactive)
+
+		a.Len(reflector.Reflect(Subscription{}).Properties["status"].Enum, 1)
+		a.Len(clone.Reflect(Subscription{}).Properties["status"].Enum, 2)
+	})
+}
+
+func TestReflectorExpandStructs(t *testing.T) {
+	t.Run("Reflect_inlines_Structs", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		referenced := Reflect(SomeStruct{})
+		reflector := &Reflector{ExpandStructs: true}
+		expanded := reflector.Reflect(SomeStruct{})
+
+		for _, name := range []string{"colorPicker", "textArea"} {
+			r.Contains(expanded.Properties, name)
+			a.Empty(expanded.Properties[name].Ref, name)
+			a.Equal(tTypeString, expanded.Properties[name].Type, name)
+
+			definition, ok := definitionName(referenced.Properties[name].Ref)
+			r.True(ok, name)
+			a.Equal(referenced.Definitions[definition], expanded.Properties[name], name)
+		}
+		a.Len(referenced.Definitions, 2)
+		a.Empty(expanded.Definitions)
+	})
+	t.Run("Reflect_references_RecursiveStructs", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{ExpandStructs: true}
+		schema := reflector.Reflect(Forest{})
+
+		trees := schema.Properties["trees"]
+		r.NotNil(trees.Items)
+		a.Equal("#/definitions/TreeNode", trees.Items.Ref)
+		r.Contains(schema.Definitions, "TreeNode")
+		a.Equal("#/definitions/TreeNode", schema.Definitions["TreeNode"].Properties["children"].Items.Ref)
+		a.Len(schema.Definitions, 1)
+
+		_, err := json.Marshal(schema)
+		a.NoError(err)
+	})
+	t.Run("Reflect_keeps_DefinitionsEmptyWithoutRecursion", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{ExpandStructs: true}
+		schema := reflector.Reflect(Household{})
+
+		a.Equal(tTypeObject, schema.Properties["head"].Type)
+		a.Equal(tTypeObject, schema.Properties["other"].Type)
+		a.Empty(schema.Definitions)
+	})
+}
+
+type Color struct {
+	R, G, B uint8
+}
+
+func (c Color) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)), nil
+}
+
+type Palette struct {
+	Colors map[Coordinate]Color  `json:"colors"`
+	Hints  map[Coordinate]*Color `json:"hints"`
+}
+
+func TestReflectTextMarshalerMap(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(Palette{})
+
+	for _, name := range []string{"colors", "hints"} {
+		property := schema.Properties[name]
+		a.Equal(tTypeObject, property.Type, name)
+		r.Contains(property.PatternProperties, ".*", name)
+		a.Equal(&Type{Type: tTypeString}, property.PatternProperties[".*"], name)
+	}
+	a.Empty(schema.Definitions)
+
+	_, err := json.Marshal(Palette{Colors: map[Coordinate]Color{{X: 1}: {R: 255}}})
+	a.NoError(err)
+}
+
+type UUID [16]byte
+
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])), nil
+}
+
+func (UUID) JSONSchemaFormat() string {
+	return "uuid"
+}
+
+type Swatch struct {
+	ID      UUID   `json:"id"`
+	Color   Color  `json:"color"`
+	Accent  *Color `json:"accent"`
+	Palette []UUID `json:"palette"`
+}
+
+func TestReflectTextMarshaler(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(Swatch{})
+
+	a.Equal(&Type{Type: tTypeString, Format: "uuid"}, schema.Properties["id"])
+	a.Equal(&Type{Type: tTypeString}, schema.Properties["color"])
+	a.Equal(&Type{Type: tTypeString}, schema.Properties["accent"])
+	r.NotNil(schema.Properties["palette"].Items)
+	a.Equal("uuid", schema.Properties["palette"].Items.Format)
+	a.Empty(schema.Definitions)
+
+	data, err := json.Marshal(Swatch{Color: Color{R: 255}})
+	r.NoError(err)
+	a.Contains(string(data), `"id":"00000000-0000-0000-0000-000000000000"`)
+	a.Contains(string(data), `"color":"#ff0000"`)
+}
+
+func TestReflectUnexportedFields(t *testing.T) {
+	reflectors := map[string]*Reflector{
+		"default":           {},
+		"FieldNameCase":     {FieldNameCase: NameCasePreserve},
+		"RequiredByDefault": {RequiredByDefault: true},
+		"EmbeddedAsAllOf":   {EmbeddedAsAllOf: true},
+	}
+
+	for name, reflector := range reflectors {
+		t.Run("Reflect_excludes_UnexportedFields_"+name, func(t *testing.T) {
+			a := assert.New(t)
+
+			schema := reflector.Reflect(SomeBaseType{})
+
+			for _, unexported := range []string{"i_am_private", "somePrivateBaseProperty", "someUnexportedUntaggedBaseProperty"} {
+				a.NotContains(schema.Properties, unexported)
+				a.NotContains(schema.Required, unexported)
+			}
+			a.Contains(schema.Properties, "some_base_property")
+		})
+	}
+}
+
+func TestReflectorOnField(t *testing.T) {
+	t.Run("Reflect_renames_Fields", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{
+			OnField: func(field reflect.StructField) (string, bool) {
+				if field.Name == "Login" {
+					return "username", true
+				}
+				return "", true
+			},
+		}
+		schema := reflector.Reflect(Account{})
+
+		a.Contains(schema.Properties, "username")
+		a.NotContains(schema.Properties, "login")
+		a.Contains(schema.Properties, "email")
+		a.Contains(schema.Properties, "family_name")
+		a.Equal([]string{"family_name", "username"}, schema.Required)
+	})
+	t.Run("Reflect_drops_Fields", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{
+			OnField: func(field reflect.StructField) (string, bool) {
+				return "", field.Name != "Email" && !field.Anonymous
+			},
+		}
+		schema := reflector.Reflect(Account{})
+
+		a.Equal([]string{"login"}, keys(schema.Properties))
+	})
+}
+
+func keys(properties map[string]*Type) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+type Grid struct {
+	Cells  [3][2]int `json:"cells"`
+	Tagged []int     `json:"tagged" jsonschema:"minItems=1,maxItems=5,uniqueItems"`
+}
+
+func TestReflectArrayConstraints(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(Grid{})
+
+	cells := schema.Properties["cells"]
+	a.Equal(intPtr(3), cells.MinItems)
+	a.Equal(intPtr(3), cells.MaxItems)
+	r.NotNil(cells.Items)
+	a.Equal(tTypeArray, cells.Items.Type)
+	a.Equal(intPtr(2), cells.Items.MinItems)
+	a.Equal(intPtr(2), cells.Items.MaxItems)
+	r.NotNil(cells.Items.Items)
+	a.Nil(cells.Items.Items.MinItems)
+	a.Nil(cells.Items.Items.MaxItems)
+
+	tagged := schema.Properties["tagged"]
+	a.Equal(intPtr(1), tagged.MinItems)
+	a.Equal(intPtr(5), tagged.MaxItems)
+	a.True(tagged.UniqueItems)
+	r.NotNil(tagged.Items)
+	a.Nil(tagged.Items.MinItems)
+	a.Nil(tagged.Items.MaxItems)
+	a.False(tagged.Items.UniqueItems)
+}
+
+type Weekday string
+
+func (Weekday) Enum() []interface{} {
+	return []interface{}{"mon", "tue", "wed"}
+}
+
+type Timetable struct {
+	Slots    map[Weekday][]string        `json:"slots"`
+	Notes    map[Weekday]json.RawMessage `json:"notes"`
+	Counters map[string]int              `json:"counters"`
+	Statuses map[Status]int              `json:"statuses"`
+}
+
+func TestReflectMapKeyNames(t *testing.T) {
+	t.Run("Reflect_constrains_EnumKeys", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := Reflect(Timetable{})
+
+		for _, name := range []string{"slots", "notes"} {
+			property := schema.Properties[name]
+			r.NotNil(property.PropertyNames, name)
+			a.Equal([]interface{}{"mon", "tue", "wed"}, property.PropertyNames.Enum, name)
+			a.Empty(property.PropertyNames.Type, name)
+		}
+		r.Contains(schema.Properties["slots"].PatternProperties, ".*")
+		a.Equal(tTypeArray, schema.Properties["slots"].PatternProperties[".*"].Type)
+	})
+	t.Run("Reflect_keeps_PlainKeysUnconstrained", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := Reflect(Timetable{})
+
+		counters := schema.Properties["counters"]
+		a.Nil(counters.PropertyNames)
+		r.Contains(counters.PatternProperties, ".*")
+		a.Nil(schema.Properties["statuses"].PropertyNames)
+	})
+	t.Run("Reflect_constrains_RegisteredEnumKeys", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{}
+		reflector.RegisterEnum(reflect.TypeOf(Status("")), StatusActive, StatusInactive)
+		schema := reflector.Reflect(Timetable{})
+
+		statuses := schema.Properties["statuses"]
+		r.NotNil(statuses.PropertyNames)
+		a.Equal([]interface{}{StatusActive, StatusInactive}, statuses.PropertyNames.Enum)
+	})
+}
+
+type Settings struct {
+	Theme string                 `json:"theme"`
+	Extra map[string]interface{} `json:"extra"`
+}
+
+func TestReflectorDisallowAdditionalProperties(t *testing.T) {
+	t.Run("Reflect_leaves_StructsOpenByDefault", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Settings{})
+
+		a.Nil(schema.AdditionalProperties)
+		a.Nil(Reflect(Household{}).Definitions["GrandfatherType"].AdditionalProperties)
+	})
+	t.Run("Reflect_closes_Structs", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{DisallowAdditionalProperties: true}
+		schema := reflector.Reflect(Settings{})
+
+		data, err := json.Marshal(schema)
+		r.NoError(err)
+
+		var decoded struct {
+			AdditionalProperties interface{} `json:"additionalProperties"`
+			Properties           map[string]struct {
+				PatternProperties map[string]struct {
+					AdditionalProperties interface{} `json:"additionalProperties"`
+				} `json:"patternProperties"`
+			} `json:"properties"`
+		}
+		r.NoError(json.Unmarshal(data, &decoded))
+
+		a.Equal(false, decoded.AdditionalProperties)
+		a.Equal(true, decoded.Properties["extra"].PatternProperties[".*"].AdditionalProperties)
+	})
+	t.Run("Reflect_closes_Definitions", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{DisallowAdditionalProperties: true}
+		schema := reflector.Reflect(Household{})
+
+		r.Contains(schema.Definitions, "GrandfatherType")
+		a.Equal("false", string(schema.Definitions["GrandfatherType"].AdditionalProperties))
+	})
+	t.Run("Reflect_leaves_EmbeddedAsAllOfOpen", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{DisallowAdditionalProperties: true, EmbeddedAsAllOf: true}
+		schema := reflector.Reflect(Account{})
+
+		r.Contains(schema.Definitions, "GrandfatherType")
+		a.Nil(schema.AdditionalProperties)
+		a.Nil(schema.Definitions["GrandfatherType"].AdditionalProperties)
+		a.NoError(schema.ValidateJSON([]byte(`{"family_name":"Doe","login":"jdoe","email":"j@doe.org"}`)))
+	})
+}
+
+type Preferences struct {
+	Locale string `json:"locale,omitempty"`
+	Inner  struct {
+		Beta bool `json:"beta,omitempty"`
+	} `json:"inner,omitempty"`
+}
+
+func TestReflectorAlwaysEmitRequired(t *testing.T) {
+	t.Run("Reflect_omits_EmptyRequiredByDefault", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		data, err := json.Marshal(Reflect(Preferences{}))
+		r.NoError(err)
+
+		a.NotContains(string(data), `"required"`)
+	})
+	t.Run("Reflect_writes_EmptyRequired", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{AlwaysEmitRequired: true}
+		data, err := json.Marshal(reflector.Reflect(Preferences{}))
+		r.NoError(err)
+
+		var decoded struct {
+			Required   []string `json:"required"`
+			Properties map[string]struct {
+				Required []string `json:"required"`
+			} `json:"properties"`
+		}
+		r.NoError(json.Unmarshal(data, &decoded))
+
+		a.NotNil(decoded.Required)
+		a.Empty(decoded.Required)
+		a.NotNil(decoded.Properties["inner"].Required)
+		a.Empty(decoded.Properties["inner"].Required)
+		a.Contains(string(data), `"required":[]`)
+	})
+	t.Run("Reflect_keeps_RequiredFields", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{AlwaysEmitRequired: true}
+		a.Equal([]string{"theme", "extra"}, reflector.Reflect(Settings{}).Required)
+	})
+}
+
+type Optionals struct {
+	Nickname *string            `json:"nickname"`
+	Age      *int               `json:"age"`
+	Parent   *GrandfatherType   `json:"parent"`
+	Name     string             `json:"name"`
+	Aliases  []string           `json:"aliases"`
+	Scores   []*int             `json:"scores"`
+	Labels   map[Weekday]string `json:"labels"`
+}
+
+type Swatches struct {
+	Color *string `json:"color" jsonschema:"enum=red|green"`
+	Size  *int    `json:"size" jsonschema:"const=3"`
+}
+
+func TestReflectorNullable(t *testing.T) {
+	t.Run("Reflect_ignores_PointersByDefault", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Optionals{})
+
+		a.Empty(schema.Properties["nickname"].Types)
+		a.False(schema.Properties["nickname"].Nullable)
+		a.Equal("#/definitions/GrandfatherType", schema.Properties["parent"].Ref)
+	})
+	t.Run("Reflect_adds_NullToTypeList", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{Nullable: NullableTypeList}
+		schema := reflector.Reflect(&Optionals{})
+
+		a.Equal([]string{tTypeString, tTypeNull}, schema.Properties["nickname"].Types)
+		a.Equal([]string{tTypeInteger, tTypeNull}, schema.Properties["age"].Types)
+		a.Empty(schema.Properties["name"].Types)
+		a.Empty(schema.Properties["aliases"].Items.Types)
+		a.Equal([]string{tTypeInteger, tTypeNull}, schema.Properties["scores"].Items.Types)
+		a.Empty(schema.Properties["labels"].PatternProperties[".*"].Types)
+		a.Equal([]interface{}{"mon", "tue", "wed"}, schema.Properties["labels"].PropertyNames.Enum)
+		a.Empty(schema.Types)
+
+		parent := schema.Properties["parent"]
+		r.Len(parent.AnyOf, 2)
+		a.Equal("#/definitions/GrandfatherType", parent.AnyOf[0].Ref)
+		a.Equal(tTypeNull, parent.AnyOf[1].Type)
+		a.Empty(schema.Definitions["GrandfatherType"].Types)
+
+		data, err := json.Marshal(schema.Properties["nickname"])
+		r.NoError(err)
+		a.Contains(string(data), `"type":["string","null"]`)
+	})
+	t.Run("Reflect_sets_OpenAPINullable", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{Nullable: NullableOpenAPI}
+		schema := reflector.Reflect(&Optionals{})
+
+		a.Equal(tTypeString, schema.Properties["nickname"].Type)
+		a.True(schema.Properties["nickname"].Nullable)
+		a.True(schema.Properties["age"].Nullable)
+		a.False(schema.Properties["name"].Nullable)
+		a.False(schema.Nullable)
+
+		parent := schema.Properties["parent"]
+		a.True(parent.Nullable)
+		r.Len(parent.AllOf, 1)
+		a.Equal("#/definitions/GrandfatherType", parent.AllOf[0].Ref)
+	})
+	t.Run("Reflect_allows_NullInTagEnum", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{Nullable: NullableTypeList}
+		schema := reflector.Reflect(Swatches{})
+
+		a.Equal([]interface{}{"red", "green", nil}, schema.Properties["color"].Enum)
+		a.NoError(schema.ValidateJSON([]byte(`{"color":null,"size":3}`)))
+		a.Error(schema.ValidateJSON([]byte(`{"color":"blue","size":3}`)))
+	})
+	t.Run("Reflect_allows_NullInTagConst", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{Nullable: NullableTypeList}
+		schema := reflector.Reflect(Swatches{})
+
+		size := schema.Properties["size"]
+		a.Nil(size.Const)
+		a.Equal([]interface{}{int64(3), nil}, size.Enum)
+		a.NoError(schema.ValidateJSON([]byte(`{"color":"red","size":null}`)))
+		a.Error(schema.ValidateJSON([]byte(`{"color":"red","size":4}`)))
+	})
+}
+
+type Lineage struct {
+	Parents [2]GrandfatherType `json:"parents"`
+	Point   [2]float64         `json:"point"`
+	Tags    []string           `json:"tags"`
+}
+
+func TestReflectorTupleArrays(t *testing.T) {
+	t.Run("Reflect_returns_ItemsSchemaByDefault", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Lineage{})
+
+		a.Nil(schema.Properties["parents"].TupleItems)
+		a.Equal("#/definitions/GrandfatherType", schema.Properties["parents"].Items.Ref)
+	})
+	t.Run("Reflect_references_StructItemsPerPosition", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{TupleArrays: true}
+		schema := reflector.Reflect(Lineage{})
+
+		parents := schema.Properties["parents"]
+		r.Len(parents.TupleItems, 2)
+		a.Equal("#/definitions/GrandfatherType", parents.TupleItems[0].Ref)
+		a.Equal("#/definitions/GrandfatherType", parents.TupleItems[1].Ref)
+		a.Equal(intPtr(2), parents.MinItems)
+		a.Equal(intPtr(2), parents.MaxItems)
+		a.Len(schema.Definitions, 1)
+		a.Equal([]string{"GrandfatherType"}, schema.UsedDefinitions())
+		a.Equal(parents.TupleItems[1], schema.JSONPointer("properties", "parents", "items", "1"))
+
+		r.Len(schema.Properties["point"].TupleItems, 2)
+		a.Equal(tTypeNumber, schema.Properties["point"].TupleItems[0].Type)
+		a.Equal(tTypeString, schema.Properties["tags"].Items.Type)
+
+		data, err := json.Marshal(parents)
+		r.NoError(err)
+		a.Contains(string(data), `"items":[{"$ref":"#/definitions/GrandfatherType"},{"$ref":"#/definitions/GrandfatherType"}]`)
+	})
+	t.Run("Reflect_lists_PrefixItemsFor2020_12", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{TupleArrays: true, Draft: Draft202012}
+		schema := reflector.Reflect(Lineage{})
+
+		parents := schema.Properties["parents"]
+		a.Nil(parents.TupleItems)
+		a.Nil(parents.Items)
+		r.Len(parents.PrefixItems, 2)
+		a.Equal("#/$defs/GrandfatherType", parents.PrefixItems[0].Ref)
+		a.Equal([]string{"GrandfatherType"}, schema.UsedDefinitions())
+		a.Equal(parents.PrefixItems[1], schema.JSONPointer("properties", "parents", "prefixItems", "1"))
+
+		data, err := json.Marshal(parents)
+		r.NoError(err)
+		a.Contains(string(data), `"prefixItems":[{"$ref":"#/$defs/GrandfatherType"},{"$ref":"#/$defs/GrandfatherType"}]`)
+		a.NotContains(string(data), `"items"`)
+
+		err = schema.ValidateJSON([]byte(`{"parents":[{"family_name":"a"},{"family_name":"b"}],"point":[1,"x"],"tags":[]}`))
+		errs, ok := err.(ValidationErrors)
+		r.True(ok)
+		r.Len(errs, 1)
+		a.Equal("/point/1", errs[0].Path)
+	})
+}
+
+type Config struct {
+	Host    string        `json:"host"`
+	Port    int           `json:"port"`
+	Debug   bool          `json:"debug"`
+	Ratio   float32       `json:"ratio"`
+	Timeout time.Duration `json:"timeout"`
+	Tags    []string      `json:"tags"`
+}
+
+func TestReflectZeroValueDefaults(t *testing.T) {
+	t.Run("Reflect_omits_ZeroValueDefaults", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := Reflect(Config{})
+
+		for name, property := range schema.Properties {
+			a.Nil(property.Default, name)
+		}
+
+		data, err := json.Marshal(schema)
+		r.NoError(err)
+		a.NotContains(string(data), `"default"`)
+	})
+	t.Run("Reflect_keeps_NonZeroDefaults", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Config{Host: "localhost", Port: 8080, Debug: true, Ratio: 0.1})
+
+		a.Equal("localhost", schema.Properties["host"].Default)
+		a.Equal(8080, schema.Properties["port"].Default)
+		a.Equal(true, schema.Properties["debug"].Default)
+		a.Equal(0.1, schema.Properties["ratio"].Default)
+		a.Nil(schema.Properties["timeout"].Default)
+	})
+}
+
+type Identified struct {
+	ID   string `json:"id" jsonschema:"required"`
+	Kind string `json:"kind" jsonschema:"required"`
+}
+
+type OptionalIDBefore struct {
+	ID string `json:"id,omitempty" jsonschema:"description=outer"`
+	Identified
+}
+
+type OptionalIDAfter struct {
+	Identified
+	ID string `json:"id,omitempty" jsonschema:"description=outer"`
+}
+
+type RequiredID struct {
+	ID string `json:"id"`
+}
+
+type OnlyOptionalID struct {
+	RequiredID
+	ID string `json:"id,omitempty"`
+}
+
+func TestReflectEmbeddedRequiredConflict(t *testing.T) {
+	for name, v := range map[string]interface{}{
+		"before": OptionalIDBefore{},
+		"after":  OptionalIDAfter{},
+	} {
+		t.Run("Reflect_prefers_OuterField_declared_"+name, func(t *testing.T) {
+			a := assert.New(t)
+
+			schema := Reflect(v)
+
+			a.Equal([]string{"kind"}, schema.Required)
+			a.Equal("outer", schema.Properties["id"].Description)
+		})
+	}
+	t.Run("Reflect_omits_EmptiedRequired", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := Reflect(OnlyOptionalID{})
+		a.Nil(schema.Required)
+
+		data, err := json.Marshal(schema)
+		r.NoError(err)
+		a.NotContains(string(data), `"required"`)
+	})
+}
+
+func TestReflectFromType(t *testing.T) {
+	t.Run("ReflectFromType_matches_ZeroValueReflect", func(t *testing.T) {
+		a := assert.New(t)
+
+		a.Equal(Reflect(TestUser{}), ReflectFromType(reflect.TypeOf(TestUser{})))
+		a.Equal(Reflect(&TestUser{}), ReflectFromType(reflect.TypeOf(&TestUser{})))
+		a.Equal(Reflect([]GrandfatherType{}), ReflectFromType(reflect.TypeOf([]GrandfatherType{})))
+	})
+	t.Run("ReflectFromType_omits_Defaults", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		populated := Reflect(Config{Host: "localhost", Port: 8080})
+		populated.RemoveDefaults()
+
+		schema := ReflectFromType(reflect.TypeOf(Config{}))
+		a.Equal(populated, schema)
+
+		data, err := json.Marshal(schema)
+		r.NoError(err)
+		a.NotContains(string(data), `"default"`)
+	})
+	t.Run("ReflectFromType_uses_ReflectorOptions", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{Nullable: NullableTypeList, NoDefaults: true}
+		schema := reflector.ReflectFromType(reflect.TypeOf(Optionals{}))
+
+		a.Equal([]string{tTypeString, tTypeNull}, schema.Properties["nickname"].Types)
+		a.Equal(reflector.Reflect(Optionals{}), schema)
+	})
+}
+
+func TestReflectNilPointerRoot(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect((*TestUser)(nil))
+
+	r.NotNil(schema.Type)
+	a.Equal(tTypeObject, schema.Type.Type)
+	a.Equal(Version, schema.Version)
+	r.Contains(schema.Properties, "id")
+	a.Nil(schema.Properties["id"].Default)
+	r.Contains(schema.Properties, "name")
+	a.Nil(schema.Properties["name"].Default)
+	a.Equal(Reflect(TestUser{}), schema)
+}
+
+type Step struct {
+	Name string `json:"name"`
+}
+
+func (Step) ItemsTitle() string { return "Step" }
+
+type Tag string
+
+func (Tag) ItemsTitle() string { return "Tag" }
+
+type Pipeline struct {
+	Steps  []Step         `json:"steps"`
+	Tags   []Tag          `json:"tags"`
+	Named  map[string]Tag `json:"named"`
+	Counts []int          `json:"counts"`
+}
+
+func TestReflectItemsTitle(t *testing.T) {
+	t.Run("Reflect_titles_Items", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := Reflect(Pipeline{})
+
+		steps := schema.Properties["steps"]
+		r.NotNil(steps.Items)
+		a.Equal("#/definitions/Step", steps.Items.Ref)
+		a.Equal("Step", steps.Items.Title)
+		a.Empty(schema.Definitions["Step"].Title)
+
+		a.Equal("Tag", schema.Properties["tags"].Items.Title)
+		a.Equal("Tag", schema.Properties["named"].PatternProperties[".*"].Title)
+		a.Empty(schema.Properties["counts"].Items.Title)
+	})
+	t.Run("Reflect_wraps_TitledItemReferences", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{WrapRefAnnotations: true}
+		schema := reflector.Reflect(Pipeline{})
+
+		items := schema.Properties["steps"].Items
+		a.Empty(items.Ref)
+		a.Equal("Step", items.Title)
+		r.Len(items.AllOf, 1)
+		a.Equal("#/definitions/Step", items.AllOf[0].Ref)
+	})
+}
+
+type Seating struct {
+	Seats map[int]string `json:"seats"`
+}
+
+func TestReflectorStrictAdditionalProperties(t *testing.T) {
+	t.Run("Reflect_leaves_MapsOpenByDefault", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(Routing{})
+
+		a.Nil(schema.Properties["weights"].AdditionalProperties)
+	})
+	t.Run("Reflect_closes_Maps", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{StrictAdditionalProperties: true}
+		schema := reflector.Reflect(Routing{})
+
+		weights := schema.Properties["weights"]
+		r.Contains(weights.PatternProperties, "^[a-z]+$")
+		a.Equal(tTypeInteger, weights.PatternProperties["^[a-z]+$"].Type)
+
+		data, err := json.Marshal(weights)
+		a.NoError(err)
+		a.Contains(string(data), `"additionalProperties":false`)
+		a.Contains(string(data), `"patternProperties":{"^[a-z]+$":{"type":"integer"}}`)
+	})
+	t.Run("Reflect_closes_MapsToKeysOfTheKeyType", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		reflector := &Reflector{StrictAdditionalProperties: true}
+		schema := reflector.Reflect(Seating{})
+
+		seats := schema.Properties["seats"]
+		r.Contains(seats.PatternProperties, patternInteger)
+
+		a.NoError(schema.ValidateJSON([]byte(`{"seats":{"1":"ann","-2":"bob"}}`)))
+
+		err := schema.ValidateJSON([]byte(`{"seats":{"1":"ann","front":"bob"}}`))
+		errs, ok := err.(ValidationErrors)
+		r.True(ok)
+		r.Len(errs, 1)
+		a.Equal("/seats/front", errs[0].Path)
+	})
+	t.Run("Reflect_keeps_RawMessageMapsOpen", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{StrictAdditionalProperties: true}
+		schema := reflector.Reflect(Timetable{})
+
+		a.Equal("true", string(schema.Properties["notes"].AdditionalProperties))
+	})
+}
@@ -1,9 +1,19 @@
 package jsonschema
 
 import (
+	"context"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"net"
 	"net/url"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -11,15 +21,30 @@ import (
 // RFC draft-wright-json-schema-validation-00, section 7.3
 // custom types
 var (
-	typeTime      = reflect.TypeOf(time.Time{}) // date-time RFC section 7.3.1
-	typeIP        = reflect.TypeOf(net.IP{})    // ipv4 and ipv6 RFC section 7.3.4, 7.3.5
-	typeURI       = reflect.TypeOf(url.URL{})   // uri RFC section 7.3.6
-	typeByteSlice = reflect.TypeOf([]byte(nil))
-	typePBEnum    = reflect.TypeOf((*protoEnum)(nil)).Elem()
-	typeEnum      = reflect.TypeOf((*enumType)(nil)).Elem()
-	typeOneOf     = reflect.TypeOf((*implicitOneOf)(nil)).Elem()
-	typeAnyOf     = reflect.TypeOf((*implicitAnyOf)(nil)).Elem()
-	typeAllOf     = reflect.TypeOf((*implicitAllOf)(nil)).Elem()
+	typeTime     = reflect.TypeOf(time.Time{}) // date-time RFC section 7.3.1
+	typeIP       = reflect.TypeOf(net.IP{})    // ipv4 and ipv6 RFC section 7.3.4, 7.3.5
+	typeURI      = reflect.TypeOf(url.URL{})   // uri RFC section 7.3.6
+	typeRegexp   = reflect.TypeOf(regexp.Regexp{})
+	typeDuration = reflect.TypeOf(time.Duration(0)) // duration RFC draft-handrews-json-schema-validation-02, section 7.3.1
+	typeRawJSON  = reflect.TypeOf(json.RawMessage(nil))
+	typePBEnum   = reflect.TypeOf((*protoEnum)(nil)).Elem()
+	typeEnum     = reflect.TypeOf((*enumType)(nil)).Elem()
+	typeOneOf    = reflect.TypeOf((*implicitOneOf)(nil)).Elem()
+	typeAnyOf    = reflect.TypeOf((*implicitAnyOf)(nil)).Elem()
+	typeAllOf    = reflect.TypeOf((*implicitAllOf)(nil)).Elem()
+
+	typeContext         = reflect.TypeOf((*context.Context)(nil)).Elem()
+	typeSchemaProvider  = reflect.TypeOf((*schemaProvider)(nil)).Elem()
+	typeBinaryMarshaler = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	typeTextMarshaler   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	typeJSONMarshaler   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+	// concurrency primitives have no useful JSON shape
+	typeSyncMap   = reflect.TypeOf((*sync.Map)(nil)).Elem()
+	typeMutex     = reflect.TypeOf((*sync.Mutex)(nil)).Elem()
+	typeRWMutex   = reflect.TypeOf((*sync.RWMutex)(nil)).Elem()
+	typeWaitGroup = reflect.TypeOf((*sync.WaitGroup)(nil)).Elem()
+	typeOnce      = reflect.TypeOf((*sync.Once)(nil)).Elem()
 )
 
 // Go code generated from protobuf enum types should fulfil this interface.
@@ -27,6 +52,22 @@ type protoEnum interface {
 	EnumDescriptor() ([]byte, []int)
 }
 
+// Element types of slices and values of maps may title the items schema.
+type itemsTitler interface {
+	ItemsTitle() string
+}
+
+// Protobuf enums may list their values by name, as protoc-gen-go generates
+// in the <Enum>_value map.
+type protoEnumValues interface {
+	EnumValues() map[string]int32
+}
+
+// Types may provide their own schema, overriding reflection.
+type schemaProvider interface {
+	JSONSchema() *Type
+}
+
 type implicitOneOf interface {
 	OneOf() []interface{}
 }
@@ -43,17 +84,114 @@ type enumType interface {
 	Enum() []interface{}
 }
 
-func reflectTime(definition Definitions, v reflect.Value) *Type {
+// Structs may limit the number of properties present in an instance.
+// RFC draft-wright-json-schema-validation-00, section 5.13, 5.14
+type implicitMinProperties interface {
+	MinProperties() int
+}
+
+type implicitMaxProperties interface {
+	MaxProperties() int
+}
+
+// Types may provide their own default, overriding the reflected value.
+type defaulter interface {
+	Default() interface{}
+}
+
+// Types marshaling to text may name the format of the string.
+type formatProvider interface {
+	JSONSchemaFormat() string
+}
+
+// Structs may describe their related resources as hyper-schema links.
+// RFC draft-wright-json-schema-hyperschema-00, section 4.2
+type linksProvider interface {
+	JSONSchemaLinks() []Link
+}
+
+func (r *Reflector) reflectTime(definition Definitions, v reflect.Value) *Type {
+	if r.TimestampInteger {
+		t := Type{
+			Type:   tTypeInteger,
+			Format: "unix-time",
+		}
+
+		if v.IsValid() {
+			if tm := v.Interface().(time.Time); !tm.IsZero() {
+				t.Default = tm.Unix()
+			}
+		}
+
+		return &t
+	}
+
 	t := Type{
 		Type:   tTypeString,
 		Format: "date-time",
 	}
 
+	// encoding/json marshals time.Time as an RFC 3339 string, so the default
+	// matches that; a zero time carries no meaningful default.
+	if v.IsValid() {
+		if tm := v.Interface().(time.Time); !tm.IsZero() {
+			t.Default = tm.Format(time.RFC3339Nano)
+		}
+	}
+
 	return &t
 }
 
+func (r *Reflector) reflectDuration(definition Definitions, v reflect.Value) *Type {
+	if !r.DurationAsString {
+		t := r.reflectInteger(definition, v)
+		t.Description = "nanoseconds"
+
+		return t
+	}
+
+	t := Type{
+		Type:   tTypeString,
+		Format: "duration",
+	}
+
+	if !isZeroValue(v) {
+		t.Default = formatISODuration(time.Duration(v.Int()))
+	}
+
+	return &t
+}
+
+// formatISODuration formats d as an ISO 8601 duration, e.g. PT1H30M.
+func formatISODuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteString("PT")
+
+	if hours := d / time.Hour; hours > 0 {
+		b.WriteString(strconv.FormatInt(int64(hours), 10) + "H")
+		d -= hours * time.Hour
+	}
+	if minutes := d / time.Minute; minutes > 0 {
+		b.WriteString(strconv.FormatInt(int64(minutes), 10) + "M")
+		d -= minutes * time.Minute
+	}
+	if d > 0 {
+		b.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S")
+	}
+
+	return b.String()
+}
+
 // ipv4 RFC section 7.3.4
-func reflectIP(definition Definitions, v reflect.Value) *Type {
+func (r *Reflector) reflectIP(definition Definitions, v reflect.Value) *Type {
 	return &Type{
 		Type:   tTypeString,
 		Format: "ipv4",
@@ -61,27 +199,134 @@ func reflectIP(definition Definitions, v reflect.Value) *Type {
 }
 
 // uri RFC section 7.3.6
-func reflectURI(definition Definitions, v reflect.Value) *Type {
+func (r *Reflector) reflectURI(definition Definitions, v reflect.Value) *Type {
 	return &Type{
 		Type:   tTypeString,
 		Format: "uri",
 	}
 }
 
-func reflectPBEnum(definition Definitions, v reflect.Value) *Type {
-	return &Type{OneOf: []*Type{
-		{Type: tTypeString},
-		{Type: tTypeInteger},
-	}}
+// regex RFC draft-handrews-json-schema-validation-01, section 7.3.8
+func (r *Reflector) reflectRegexp(definition Definitions, v reflect.Value) *Type {
+	return &Type{
+		Type:   tTypeString,
+		Format: "regex",
+	}
+}
+
+// reflectOpaque describes a type whose internals must not be reflected,
+// such as a sync primitive, as a permissive object. The value is never
+// used as a default since it may hold locks.
+func (r *Reflector) reflectOpaque(definition Definitions, v reflect.Value) *Type {
+	return &Type{
+		Type:                 tTypeObject,
+		AdditionalProperties: []byte("true"),
+	}
+}
+
+// reflectTextMarshaler describes a type encoding/json marshals through
+// its MarshalText method, which is always a string.
+func (r *Reflector) reflectTextMarshaler(definition Definitions, v reflect.Value) *Type {
+	t := Type{
+		Type: tTypeString,
+	}
+
+	if v.IsValid() && v.CanInterface() {
+		if impl, ok := v.Interface().(formatProvider); ok {
+			t.Format = impl.JSONSchemaFormat()
+		}
+	}
+
+	return &t
 }
 
-func reflectEnum(definition Definitions, v reflect.Value) *Type {
-	variants := v.Interface().(enumType).Enum()
+// binary data RFC draft-wright-json-schema-hyperschema-00, section 4.3
+func (r *Reflector) reflectBinaryMarshaler(definition Definitions, v reflect.Value) *Type {
+	return &Type{
+		Type: tTypeString,
+		Media: &Type{
+			BinaryEncoding: "base64",
+		},
+	}
+}
+
+// reflectPBEnum describes a protobuf enum by its numbers when it lists its
+// values, and as either a name or a number otherwise.
+func (r *Reflector) reflectPBEnum(definition Definitions, v reflect.Value) *Type {
+	impl, ok := v.Interface().(protoEnumValues)
+	if !ok {
+		return &Type{OneOf: []*Type{
+			{Type: tTypeString},
+			{Type: tTypeInteger},
+		}}
+	}
+
+	values := impl.EnumValues()
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if values[names[i]] != values[names[j]] {
+			return values[names[i]] < values[names[j]]
+		}
+		return names[i] < names[j] // aliases share a number
+	})
+
+	typ := &Type{Type: tTypeInteger}
+	for _, name := range names {
+		number := values[name]
+		if len(typ.Enum) == 0 || typ.Enum[len(typ.Enum)-1] != number {
+			typ.Enum = append(typ.Enum, number)
+		}
+		typ.OneOf = append(typ.OneOf, &Type{Title: name, Const: number})
+	}
+
+	return typ
+}
+
+// indirectVariants dereferences pointer variants, so variants are detected
+// and emitted by the values they point to.
+func indirectVariants(variants []interface{}) []interface{} {
+	indirect := make([]interface{}, len(variants))
+
+	for idx, variant := range variants {
+		value := reflect.ValueOf(variant)
+		if value.Kind() == reflect.Ptr && !value.IsNil() {
+			variant = value.Elem().Interface()
+		}
+		indirect[idx] = variant
+	}
+
+	return indirect
+}
+
+func (r *Reflector) reflectEnum(definition Definitions, v reflect.Value) *Type {
+	variants := indirectVariants(v.Interface().(enumType).Enum())
+
+	// struct variants are listed as the objects they marshal to, without
+	// reflecting the struct itself into a definition
+	if reflect.TypeOf(variants[0]).Kind() == reflect.Struct {
+		objects := make([]interface{}, len(variants))
+		for idx, variant := range variants {
+			objects[idx] = normalize(variant)
+		}
+
+		typ := &Type{
+			Type: tTypeObject,
+			Enum: objects,
+		}
+
+		handleDefaultValue(typ, v)
+
+		return typ
+	}
 
 	variantValueOf := reflect.ValueOf(variants[0])
 	variantTypeOf := reflect.TypeOf(variants[0])
 
-	vType := reflectType(definition, variantTypeOf, variantValueOf, false)
+	vType := r.reflectType(definition, variantTypeOf, variantValueOf, false)
 
 	typ := &Type{
 		Type: vType.Type,
@@ -93,13 +338,43 @@ func reflectEnum(definition Definitions, v reflect.Value) *Type {
 	return typ
 }
 
-func reflectOneOf(definition Definitions, v reflect.Value) *Type {
-	variants := v.Interface().(implicitOneOf).OneOf()
+// reflectRegisteredEnum describes a type whose values were registered
+// with RegisterEnum.
+func (r *Reflector) reflectRegisteredEnum(definition Definitions, v reflect.Value, values []interface{}) *Type {
+	typ := &Type{
+		Type: kindType(v.Kind()),
+		Enum: values,
+	}
+
+	handleDefaultValue(typ, v)
+
+	return typ
+}
+
+// kindType returns the JSON type values of kind k marshal to.
+func kindType(k reflect.Kind) string {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return tTypeInteger
+	case reflect.Float32, reflect.Float64:
+		return tTypeNumber
+	case reflect.Bool:
+		return tTypeBoolean
+	case reflect.String:
+		return tTypeString
+	}
+
+	return ""
+}
+
+func (r *Reflector) reflectOneOf(definition Definitions, v reflect.Value) *Type {
+	variants := indirectVariants(v.Interface().(implicitOneOf).OneOf())
 
 	oneOf := make([]*Type, len(variants))
 
 	for idx, variant := range variants {
-		oneOf[idx] = reflectType(definition,
+		oneOf[idx] = r.reflectType(definition,
 			reflect.TypeOf(variant),
 			reflect.ValueOf(variant), false)
 	}
@@ -114,13 +389,13 @@ func reflectOneOf(definition Definitions, v reflect.Value) *Type {
 	return typ
 }
 
-func reflectAnyOf(definition Definitions, v reflect.Value) *Type {
-	variants := v.Interface().(implicitAnyOf).AnyOf()
+func (r *Reflector) reflectAnyOf(definition Definitions, v reflect.Value) *Type {
+	variants := indirectVariants(v.Interface().(implicitAnyOf).AnyOf())
 
 	anyOf := make([]*Type, len(variants))
 
 	for idx, variant := range variants {
-		anyOf[idx] = reflectType(definition,
+		anyOf[idx] = r.reflectType(definition,
 			reflect.TypeOf(variant),
 			reflect.ValueOf(variant), false)
 	}
@@ -135,13 +410,13 @@ func reflectAnyOf(definition Definitions, v reflect.Value) *Type {
 	return typ
 }
 
-func reflectAllOf(definition Definitions, v reflect.Value) *Type {
-	variants := v.Interface().(implicitAllOf).AllOf()
+func (r *Reflector) reflectAllOf(definition Definitions, v reflect.Value) *Type {
+	variants := indirectVariants(v.Interface().(implicitAllOf).AllOf())
 
 	allOf := make([]*Type, len(variants))
 
 	for idx, variant := range variants {
-		allOf[idx] = reflectType(definition,
+		allOf[idx] = r.reflectType(definition,
 			reflect.TypeOf(variant),
 			reflect.ValueOf(variant), false)
 	}
@@ -156,6 +431,98 @@ func reflectAllOf(definition Definitions, v reflect.Value) *Type {
 	return typ
 }
 
+// reflectSchemaProvider returns a copy of the schema the value provides,
+// so tags applied to one field don't change the schema of another or the
+// one the type keeps. It returns nil when the value provides none.
+func (r *Reflector) reflectSchemaProvider(definition Definitions, v reflect.Value) *Type {
+	return v.Interface().(schemaProvider).JSONSchema().deepCopy()
+}
+
+func (r *Reflector) reflectImplementations(definition Definitions, t reflect.Type, implementations []reflect.Type) *Type {
+	oneOf := make([]*Type, len(implementations))
+
+	for idx, implementation := range implementations {
+		oneOf[idx] = r.reflectType(definition,
+			implementation,
+			reflect.Zero(implementation), false)
+	}
+
+	typ := &Type{
+		OneOf: oneOf,
+	}
+
+	// the mapping is completed by discriminate once every variant is, as
+	// variants of recursive unions are still in progress here
+	if property, ok := r.Discriminators[t]; ok {
+		typ.Discriminator = &Discriminator{PropertyName: property}
+	}
+
+	return typ
+}
+
+// discriminate maps the const discriminator property of each referenced
+// variant of typ to its reference, requiring the property in every
+// variant. It runs after reflection, when all definitions are complete.
+func discriminate(definitions Definitions, typ *Type) {
+	if typ.Discriminator == nil || typ.Discriminator.Mapping != nil {
+		return
+	}
+
+	property := typ.Discriminator.PropertyName
+	typ.Discriminator.Mapping = map[string]string{}
+
+	for _, variant := range typ.OneOf {
+		name, ok := definitionName(variant.Ref)
+		if !ok || definitions[name] == nil {
+			continue
+		}
+
+		def := definitions[name]
+		def.AddRequired(property)
+
+		if discriminant, ok := def.Properties[property]; ok && discriminant.Const != nil {
+			typ.Discriminator.Mapping[fmt.Sprint(discriminant.Const)] = variant.Ref
+		}
+	}
+}
+
+func applyPropertiesRange(dst *Type, v reflect.Value) {
+	if !v.CanInterface() {
+		return
+	}
+
+	if impl, ok := v.Interface().(implicitMinProperties); ok {
+		dst.MinProperties = impl.MinProperties()
+	}
+
+	if impl, ok := v.Interface().(implicitMaxProperties); ok {
+		dst.MaxProperties = impl.MaxProperties()
+	}
+}
+
+// applyItemsTitle titles the schema of slice items or map values whose
+// type implements ItemsTitle() string, wrapping references if configured.
+func (r *Reflector) applyItemsTitle(dst *Type, v reflect.Value) *Type {
+	impl, ok := v.Interface().(itemsTitler)
+	if !ok {
+		return dst
+	}
+
+	dst.Title = impl.ItemsTitle()
+
+	return r.wrapRef(dst)
+}
+
+func applyLinks(dst *Type, v reflect.Value) {
+	if !v.CanInterface() {
+		return
+	}
+
+	if impl, ok := v.Interface().(linksProvider); ok {
+		dst.Links = impl.JSONSchemaLinks()
+	}
+}
+
 func getSliceValue(v reflect.Value) reflect.Value {
 	if v.Len() > 0 {
 		return v.Index(0)
@@ -164,70 +531,351 @@ func getSliceValue(v reflect.Value) reflect.Value {
 	return reflect.New(v.Type().Elem())
 }
 
-func reflectSlice(definition Definitions, v reflect.Value) *Type {
+func (r *Reflector) reflectSlice(definition Definitions, v reflect.Value) *Type {
 	returnType := newType("")
 
-	if v.Type().Kind() == reflect.Array {
-		returnType.MinItems = v.Type().Len()
-		returnType.MaxItems = v.Type().Len()
-	}
-
-	elemValue := reflect.New(v.Type().Elem())
-
-	switch v.Type() {
-	case typeByteSlice:
+	switch {
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		// named byte slices, e.g. type Blob []byte, marshal as base64 too
 		returnType.Type = tTypeString
 		returnType.Media = &Type{
 			BinaryEncoding: "base64",
 		}
 	default:
-		returnType.Type = "array"
-		returnType.Items = reflectType(definition, elemValue.Type(), elemValue, false)
+		return r.reflectArray(definition, v)
+	}
+
+	return returnType
+}
+
+// reflectBinaryArray describes a fixed size byte array, e.g. a hash, as
+// base64 binary of a fixed encoded length, for fields marshaling it so.
+// encoding/json itself marshals byte arrays as arrays of integers.
+func reflectBinaryArray(t reflect.Type) *Type {
+	size := base64.StdEncoding.EncodedLen(t.Len())
+
+	return &Type{
+		Type:      tTypeString,
+		Format:    "byte",
+		MinLength: intPtr(size),
+		MaxLength: intPtr(size),
+		Media: &Type{
+			BinaryEncoding: "base64",
+		},
+	}
+}
+
+// reflectArray describes a slice or array as an array of its elements,
+// including bytes that reflectSlice describes as base64 binary.
+func (r *Reflector) reflectArray(definition Definitions, v reflect.Value) *Type {
+	returnType := newType(tTypeArray)
+
+	if r.TupleArrays && v.Kind() == reflect.Array {
+		items := make([]*Type, v.Len())
+		for i := range items {
+			elem := v.Index(i)
+			items[i] = r.applyItemsTitle(r.reflectType(definition, elem.Type(), elem, false), elem)
+		}
+		returnType.MinItems = intPtr(v.Len())
+		returnType.MaxItems = intPtr(v.Len())
+
+		// draft 2020-12 moved tuples from items to prefixItems
+		if r.Draft >= Draft202012 {
+			returnType.PrefixItems = items
+		} else {
+			returnType.TupleItems = items
+		}
+
+		return returnType
 	}
 
-	defaults := make([]interface{}, 0)
-	for i := 0; i < v.Len(); i++ {
-		defaults = append(defaults, v.Index(i).Interface())
+	elemValue := reflect.New(v.Type().Elem())
+	if elem, ok := homogeneousElem(v); ok {
+		elemValue = reflect.New(elem.Type())
+	}
+	returnType.Items = r.reflectType(definition, elemValue.Type().Elem(), elemValue.Elem(), false)
+	returnType.Items = r.applyItemsTitle(returnType.Items, elemValue)
+
+	if v.Type().Kind() == reflect.Array {
+		returnType.MinItems = intPtr(v.Type().Len())
+		returnType.MaxItems = intPtr(v.Type().Len())
 	}
 
 	return returnType
 }
 
-func reflectMap(definitions Definitions, v reflect.Value) *Type {
+// homogeneousElem returns the first element of a populated slice of
+// interfaces when all its elements have the same concrete type.
+func homogeneousElem(v reflect.Value) (reflect.Value, bool) {
+	if v.Type().Elem().Kind() != reflect.Interface || v.Len() == 0 {
+		return reflect.Value{}, false
+	}
+
+	first := v.Index(0).Elem()
+	if !first.IsValid() {
+		return reflect.Value{}, false
+	}
+
+	for i := 1; i < v.Len(); i++ {
+		elem := v.Index(i).Elem()
+		if !elem.IsValid() || elem.Type() != first.Type() {
+			return reflect.Value{}, false
+		}
+	}
+
+	return first, true
+}
+
+func isByteArray(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8
+}
+
+// isBytes reports whether t is a slice or array of bytes, of any name.
+func isBytes(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8
+}
+
+// reflectMap describes a map as an object. encoding/json marshals keys of
+// string kinds as they are, others through encoding.TextMarshaler, and
+// integers in decimal, so keys are always JSON strings.
+func (r *Reflector) reflectMap(definitions Definitions, v reflect.Value) *Type {
 	val := v.Type().Elem()
 
+	// values are arbitrary JSON, any property is allowed
+	if val == typeRawJSON {
+		return &Type{
+			Type:                 tTypeObject,
+			AdditionalProperties: []byte("true"),
+			PropertyNames:        r.reflectKeyNames(v.Type().Key()),
+		}
+	}
+
+	valValue := reflect.New(val)
+
+	valType := r.reflectType(definitions, val, valValue.Elem(), false)
+
 	rt := &Type{
 		Type: tTypeObject,
 		PatternProperties: map[string]*Type{
-			".*": reflectType(definitions, val, reflect.New(val), false),
+			".*": r.applyItemsTitle(valType, valValue),
 		},
+		PropertyNames: r.reflectKeyNames(v.Type().Key()),
+	}
+
+	// keys must match the pattern for additionalProperties to reject any
+	if r.StrictAdditionalProperties {
+		rt.PatternProperties = map[string]*Type{
+			keyPattern(v.Type().Key(), rt.PropertyNames): rt.PatternProperties[".*"],
+		}
+		rt.AdditionalProperties = []byte("false")
 	}
-	delete(rt.PatternProperties, "additionalProperties")
 
 	return rt
 }
 
-func reflectInteger(definitions Definitions, v reflect.Value) *Type {
+// keyPattern returns a pattern matching the keys of a map of key type key
+// as encoding/json marshals them, narrowed by the names reflectKeyNames
+// constrains them to.
+func keyPattern(key reflect.Type, names *Type) string {
+	// encoding/json prefers MarshalText over formatting integers
+	if key.Kind() != reflect.String && key.Implements(typeTextMarshaler) {
+		return ".*"
+	}
+
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return patternInteger
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return patternUnsignedInteger
+	}
+
+	if names != nil && len(names.Enum) > 0 {
+		values := make([]string, len(names.Enum))
+		for i, value := range names.Enum {
+			values[i] = regexp.QuoteMeta(fmt.Sprint(value))
+		}
+
+		return "^(" + strings.Join(values, "|") + ")$"
+	}
+
+	if names != nil && names.Pattern != "" {
+		return names.Pattern
+	}
+
+	return ".*"
+}
+
+// reflectKeyNames constrains map keys of a named string type, such as an
+// enum, by its values, pattern or format. It returns nil for other keys.
+func (r *Reflector) reflectKeyNames(key reflect.Type) *Type {
+	if key.Kind() != reflect.String || key.PkgPath() == "" {
+		return nil
+	}
+
+	keyType := r.reflectType(Definitions{}, key, reflect.New(key).Elem(), false)
+
+	if len(keyType.Enum) == 0 && keyType.Pattern == "" && keyType.Format == "" {
+		return nil
+	}
+
+	return &Type{
+		Enum:    keyType.Enum,
+		Pattern: keyType.Pattern,
+		Format:  keyType.Format,
+	}
+}
+
+func (r *Reflector) reflectInteger(definitions Definitions, v reflect.Value) *Type {
+	if r.NumberAsString {
+		switch v.Kind() {
+		case reflect.Int64:
+			return reflectNumberString(v, patternInteger)
+		case reflect.Uint64:
+			return reflectNumberString(v, patternUnsignedInteger)
+		}
+	}
+
 	typ := &Type{
 		Type: tTypeInteger,
 	}
 
+	if r.EmitNumberFormats {
+		switch v.Kind() {
+		case reflect.Int32:
+			typ.Format = "int32"
+		case reflect.Int64:
+			typ.Format = "int64"
+		}
+	}
+
 	handleDefaultValue(typ, v)
 
 	return typ
 }
 
-func reflectNumber(definitions Definitions, v reflect.Value) *Type {
+func (r *Reflector) reflectNumber(definitions Definitions, v reflect.Value) *Type {
+	if r.NumberAsString && v.Kind() == reflect.Float64 {
+		return reflectNumberString(v, patternNumber)
+	}
+
 	typ := &Type{
 		Type: tTypeNumber,
 	}
 
+	if r.EmitNumberFormats {
+		switch v.Kind() {
+		case reflect.Float32:
+			typ.Format = "float"
+		case reflect.Float64:
+			typ.Format = "double"
+		}
+	}
+
 	handleDefaultValue(typ, v)
 
+	// float32 defaults are stored as the float64 of their shortest decimal
+	// form, so 0.1 doesn't marshal as 0.10000000149011612.
+	if typ.Default != nil && v.Kind() == reflect.Float32 {
+		typ.Default, _ = strconv.ParseFloat(strconv.FormatFloat(v.Float(), 'g', -1, 32), 64)
+	}
+
+	return typ
+}
+
+// Patterns of numbers encoded as strings.
+const (
+	patternInteger         = `^-?[0-9]+$`
+	patternUnsignedInteger = `^[0-9]+$`
+	patternNumber          = `^-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?$`
+)
+
+// reflectNumberString describes a number encoded as a string, which keeps
+// precision beyond 2^53 that many JSON consumers lose.
+func reflectNumberString(v reflect.Value, pattern string) *Type {
+	typ := &Type{
+		Type:    tTypeString,
+		Pattern: pattern,
+	}
+
+	if !isZeroValue(v) {
+		typ.Default = fmt.Sprint(v.Interface())
+	}
+
+	return typ
+}
+
+// patternBoolean matches a boolean encoded as a string.
+const patternBoolean = `^(true|false)$`
+
+// quoteType converts typ, reflected for a field of type t, to the string the
+// json tag's string option encodes the field as. Values such as the enum
+// and default are quoted too, and keywords only numbers are validated
+// against are kept as annotations. Kinds the option doesn't apply to are
+// returned unchanged.
+func quoteType(typ *Type, t reflect.Type) *Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var pattern string
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		pattern = patternInteger
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		pattern = patternUnsignedInteger
+	case reflect.Float32, reflect.Float64:
+		pattern = patternNumber
+	case reflect.Bool:
+		pattern = patternBoolean
+	default:
+		return typ
+	}
+
+	// types marshaling themselves, e.g. as strings already, aren't quoted
+	if typ.Type != tTypeInteger && typ.Type != tTypeNumber && typ.Type != tTypeBoolean {
+		return typ
+	}
+
+	typ.Type = tTypeString
+	typ.Format = ""
+	typ.Pattern = pattern
+
+	for i, name := range typ.Types {
+		if name != tTypeNull {
+			typ.Types[i] = tTypeString
+		}
+	}
+
+	typ.Enum = quoteValues(typ.Enum)
+	typ.Examples = quoteValues(typ.Examples)
+	if typ.Default != nil {
+		typ.Default = fmt.Sprint(typ.Default)
+	}
+	if typ.Const != nil {
+		typ.Const = fmt.Sprint(typ.Const)
+	}
+
 	return typ
 }
 
-func reflectBool(definitions Definitions, v reflect.Value) *Type {
+// quoteValues returns values formatted as strings, keeping nulls.
+func quoteValues(values []interface{}) []interface{} {
+	if values == nil {
+		return nil
+	}
+
+	quoted := make([]interface{}, len(values))
+	for i, value := range values {
+		if value != nil {
+			value = fmt.Sprint(value)
+		}
+		quoted[i] = value
+	}
+
+	return quoted
+}
+
+func (r *Reflector) reflectBool(definitions Definitions, v reflect.Value) *Type {
 	typ := &Type{
 		Type: tTypeBoolean,
 	}
@@ -237,7 +885,7 @@ func reflectBool(definitions Definitions, v reflect.Value) *Type {
 	return typ
 }
 
-func reflectString(definitions Definitions, v reflect.Value) *Type {
+func (r *Reflector) reflectString(definitions Definitions, v reflect.Value) *Type {
 	typ := &Type{
 		Type: tTypeString,
 	}
@@ -247,7 +895,7 @@ func reflectString(definitions Definitions, v reflect.Value) *Type {
 	return typ
 }
 
-func reflectInterface(definitions Definitions, t reflect.Type, v reflect.Value) *Type {
+func (r *Reflector) reflectInterface(definitions Definitions, t reflect.Type, v reflect.Value) *Type {
 	typ := &Type{
 		Type:                 tTypeObject,
 		AdditionalProperties: []byte("true"),
@@ -258,8 +906,101 @@ func reflectInterface(definitions Definitions, t reflect.Type, v reflect.Value)
 	return typ
 }
 
+// nullable allows null for typ in the configured style.
+func (r *Reflector) nullable(typ *Type) *Type {
+	switch r.Nullable {
+	case NullableTypeList:
+		return nullableTypeList(typ)
+	case NullableOpenAPI:
+		if typ.Ref != "" {
+			return &Type{AllOf: []*Type{typ}, Nullable: true}
+		}
+
+		typ.Nullable = true
+	}
+
+	return typ
+}
+
+// allowNullValue lets the const or enum of a nullable typ accept null,
+// turning a const into an enum of it and null.
+func allowNullValue(typ *Type) {
+	if typ.Const != nil {
+		typ.Enum = []interface{}{typ.Const}
+		typ.Const = nil
+	}
+
+	if len(typ.Enum) == 0 {
+		return
+	}
+
+	for _, value := range typ.Enum {
+		if value == nil {
+			return
+		}
+	}
+	typ.Enum = append(typ.Enum[:len(typ.Enum):len(typ.Enum)], nil)
+}
+
+// nullableTypeList allows null for typ by adding it to the type list, or
+// to an anyOf for references.
+func nullableTypeList(typ *Type) *Type {
+	switch {
+	case typ.Ref != "":
+		return &Type{AnyOf: []*Type{typ, {Type: tTypeNull}}}
+	case len(typ.Types) > 0:
+		if !containsString(typ.Types, tTypeNull) {
+			typ.Types = append(typ.Types, tTypeNull)
+		}
+	case typ.Type != "":
+		typ.Types = []string{typ.Type, tTypeNull}
+	default:
+		return typ // any JSON, including null
+	}
+
+	if len(typ.Enum) > 0 {
+		typ.Enum = append(typ.Enum[:len(typ.Enum):len(typ.Enum)], nil)
+	}
+
+	return typ
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyDefaulter sets the default provided by a value of type t
+// implementing defaulter, taking precedence over the reflected value.
+// Nil pointers provide the default of their zero element.
+func applyDefaulter(dst *Type, t reflect.Type, v reflect.Value) {
+	for t.Kind() == reflect.Ptr && (!v.IsValid() || v.Kind() == reflect.Ptr && v.IsNil()) {
+		t = t.Elem()
+		v = reflect.Zero(t)
+	}
+
+	if !v.IsValid() || !v.CanInterface() {
+		return
+	}
+
+	if impl, ok := v.Interface().(defaulter); ok {
+		dst.Default = impl.Default()
+	}
+}
+
+// handleDefaultValue sets the value as the default unless it is the zero
+// value, which says nothing about the field but that it was left unset.
 func handleDefaultValue(dst *Type, v reflect.Value) {
-	if v.IsValid() {
+	if !isZeroValue(v) {
 		dst.Default = v.Interface()
 	}
 }
+
+func isZeroValue(v reflect.Value) bool {
+	return !v.IsValid() || reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
@@ -3,6 +3,10 @@ package jsonschema
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // Version is the JSON Schema version.
@@ -10,6 +14,93 @@ import (
 // RFC draft-wright-json-schema-00, section 6
 var Version = "http://json-schema.org/draft-07/schema#"
 
+// Draft is a JSON Schema draft the reflected schema conforms to.
+type Draft int
+
+// Drafts written as the root $schema, in order of publication. The zero
+// value is draft-07.
+const (
+	Draft04 Draft = iota - 2
+	Draft06
+	Draft07
+	Draft201909
+	Draft202012
+)
+
+// URI returns the meta-schema URI of the draft. Draft-07 uses Version.
+func (d Draft) URI() string {
+	switch d {
+	case Draft04:
+		return "http://json-schema.org/draft-04/schema#"
+	case Draft06:
+		return "http://json-schema.org/draft-06/schema#"
+	case Draft201909:
+		return "https://json-schema.org/draft/2019-09/schema"
+	case Draft202012:
+		return "https://json-schema.org/draft/2020-12/schema"
+	}
+
+	return Version
+}
+
+// definitionsKeyword returns the keyword holding definitions, which
+// became $defs in draft 2019-09.
+func (d Draft) definitionsKeyword() string {
+	switch d {
+	case Draft201909, Draft202012:
+		return "$defs"
+	}
+
+	return "definitions"
+}
+
+// definitionsPrefix returns the JSON pointer prefix of references to
+// definitions.
+func (d Draft) definitionsPrefix() string {
+	return "#/" + d.definitionsKeyword() + "/"
+}
+
+// exclusiveBounds rewrites the exclusive bounds of t in the form of the
+// draft: flags next to minimum and maximum in draft-04, numbers since.
+func (d Draft) exclusiveBounds(t *Type) {
+	if d < Draft06 {
+		t.Minimum, t.ExclusiveMinimum, t.ExclusiveMinimumValue = exclusiveFlag(t.Minimum, t.ExclusiveMinimum, t.ExclusiveMinimumValue, 1)
+		t.Maximum, t.ExclusiveMaximum, t.ExclusiveMaximumValue = exclusiveFlag(t.Maximum, t.ExclusiveMaximum, t.ExclusiveMaximumValue, -1)
+		return
+	}
+
+	t.Minimum, t.ExclusiveMinimum, t.ExclusiveMinimumValue = exclusiveValue(t.Minimum, t.ExclusiveMinimum, t.ExclusiveMinimumValue)
+	t.Maximum, t.ExclusiveMaximum, t.ExclusiveMaximumValue = exclusiveValue(t.Maximum, t.ExclusiveMaximum, t.ExclusiveMaximumValue)
+}
+
+// exclusiveFlag turns an exclusive bound value into the bound and its
+// flag, unless the inclusive bound is tighter. sign is 1 for lower bounds
+// and -1 for upper bounds.
+func exclusiveFlag(bound *float64, flag bool, value *float64, sign float64) (*float64, bool, *float64) {
+	if value == nil {
+		return bound, flag, nil
+	}
+
+	if bound == nil || sign**value >= sign**bound {
+		return value, true, nil
+	}
+
+	return bound, flag, nil
+}
+
+// exclusiveValue turns a flagged bound into an exclusive bound value.
+func exclusiveValue(bound *float64, flag bool, value *float64) (*float64, bool, *float64) {
+	if !flag {
+		return bound, false, value
+	}
+
+	if value == nil {
+		value = bound
+	}
+
+	return nil, false, value
+}
+
 // Definitions hold schema definitions.
 // http://json-schema.org/latest/json-schema-validation.html#rfc.section.5.26
 // RFC draft-wright-json-schema-validation-00, section 5.26
@@ -20,6 +111,10 @@ type Definitions map[string]*Type
 type Schema struct {
 	*Type
 	Definitions Definitions `json:"definitions,omitempty"`
+
+	// Draft selects the keyword definitions are written under, $defs
+	// since draft 2019-09 and definitions before.
+	Draft Draft `json:"-"`
 }
 
 // Type represents a JSON Schema object type.
@@ -27,19 +122,21 @@ type Type struct {
 	// RFC draft-wright-json-schema-00
 	Version string `json:"$schema,omitempty"` // section 6.1
 	Ref     string `json:"$ref,omitempty"`    // section 7
+	// RFC draft-wright-json-schema-01, section 9.2
+	ID string `json:"$id,omitempty"`
 	// RFC draft-wright-json-schema-validation-00, section 5
-	MultipleOf           int              `json:"multipleOf,omitempty"`           // section 5.1
-	Maximum              int              `json:"maximum,omitempty"`              // section 5.2
+	MultipleOf           *float64         `json:"multipleOf,omitempty"`           // section 5.1
+	Maximum              *float64         `json:"maximum,omitempty"`              // section 5.2
 	ExclusiveMaximum     bool             `json:"exclusiveMaximum,omitempty"`     // section 5.3
-	Minimum              int              `json:"minimum,omitempty"`              // section 5.4
+	Minimum              *float64         `json:"minimum,omitempty"`              // section 5.4
 	ExclusiveMinimum     bool             `json:"exclusiveMinimum,omitempty"`     // section 5.5
-	MaxLength            int              `json:"maxLength,omitempty"`            // section 5.6
-	MinLength            int              `json:"minLength,omitempty"`            // section 5.7
+	MaxLength            *int             `json:"maxLength,omitempty"`            // section 5.6
+	MinLength            *int             `json:"minLength,omitempty"`            // section 5.7
 	Pattern              string           `json:"pattern,omitempty"`              // section 5.8
 	AdditionalItems      *Type            `json:"additionalItems,omitempty"`      // section 5.9
 	Items                *Type            `json:"items,omitempty"`                // section 5.9
-	MaxItems             int              `json:"maxItems,omitempty"`             // section 5.10
-	MinItems             int              `json:"minItems,omitempty"`             // section 5.11
+	MaxItems             *int             `json:"maxItems,omitempty"`             // section 5.10
+	MinItems             *int             `json:"minItems,omitempty"`             // section 5.11
 	UniqueItems          bool             `json:"uniqueItems,omitempty"`          // section 5.12
 	MaxProperties        int              `json:"maxProperties,omitempty"`        // section 5.13
 	MinProperties        int              `json:"minProperties,omitempty"`        // section 5.14
@@ -50,27 +147,204 @@ type Type struct {
 	Dependencies         map[string]*Type `json:"dependencies,omitempty"`         // section 5.19
 	Enum                 []interface{}    `json:"enum,omitempty"`                 // section 5.20
 	Type                 string           `json:"type,omitempty"`                 // section 5.21
+	Types                []string         `json:"-"`                              // section 5.21, marshaled as type when set
+	TupleItems           []*Type          `json:"-"`                              // section 5.9, marshaled as items when set
+	PrefixItems          []*Type          `json:"prefixItems,omitempty"`          // draft 2020-12 tuple items
 	AllOf                []*Type          `json:"allOf,omitempty"`                // section 5.22
 	AnyOf                []*Type          `json:"anyOf,omitempty"`                // section 5.23
 	OneOf                []*Type          `json:"oneOf,omitempty"`                // section 5.24
 	Not                  *Type            `json:"not,omitempty"`                  // section 5.25
 	Definitions          Definitions      `json:"definitions,omitempty"`          // section 5.26
+	// RFC draft-wright-json-schema-validation-01, section 6.3, 6.5
+	ExclusiveMaximumValue *float64 `json:"-"` // marshaled as exclusiveMaximum when set
+	ExclusiveMinimumValue *float64 `json:"-"` // marshaled as exclusiveMinimum when set
 	// RFC draft-wright-json-schema-validation-00, section 6, 7
 	Title       string      `json:"title,omitempty"`       // section 6.1
 	Description string      `json:"description,omitempty"` // section 6.1
 	Default     interface{} `json:"default,omitempty"`     // section 6.2
 	Format      string      `json:"format,omitempty"`      // section 7
 	// RFC draft-wright-json-schema-hyperschema-00, section 4
+	Links          []Link `json:"links,omitempty"`          // section 4.2
 	Media          *Type  `json:"media,omitempty"`          // section 4.3
 	BinaryEncoding string `json:"binaryEncoding,omitempty"` // section 4.3
 	// RFC http://json-schema.org/draft-07/json-schema-validation.html#general
 	If   *Type `json:"if,omitempty,omitempty"`
 	Then *Type `json:"then,omitempty,omitempty"`
 	Else *Type `json:"else,omitempty,omitempty"`
+	// RFC draft-handrews-json-schema-validation-01, section 6, 10
+	Const         interface{}   `json:"const,omitempty"`         // section 6.1.3
+	PropertyNames *Type         `json:"propertyNames,omitempty"` // section 6.5.8
+	ReadOnly      bool          `json:"readOnly,omitempty"`      // section 10.3
+	WriteOnly     bool          `json:"writeOnly,omitempty"`     // section 10.3
+	Examples      []interface{} `json:"examples,omitempty"`      // section 10.4
+	// RFC draft-handrews-json-schema-01, section 9
+	Comment string `json:"$comment,omitempty"`
+	// OpenAPI 3.0, Schema Object
+	Discriminator *Discriminator `json:"discriminator,omitempty"`
+	Nullable      bool           `json:"nullable,omitempty"`
+	// Extensions
+	EnumVarNames []string `json:"x-enum-varnames,omitempty"` // names of Enum values for code generators
+}
+
+// Discriminator names the property whose value tells which oneOf variant
+// an instance is.
+// OpenAPI 3.0, Discriminator Object
+type Discriminator struct {
+	PropertyName string            `json:"propertyName"`
+	Mapping      map[string]string `json:"mapping,omitempty"` // property value to variant $ref
+}
+
+// Link describes a resource related to an instance, such as an API
+// endpoint.
+// RFC draft-wright-json-schema-hyperschema-00, section 5
+type Link struct {
+	Rel  string `json:"rel"`  // section 5.2
+	Href string `json:"href"` // section 5.1, a URI template
+}
+
+// plainType has the fields of Type without its methods, to marshal
+// it without recursing into MarshalJSON.
+type plainType Type
+
+// MarshalJSON writes Types as the type keyword when the instance may be
+// of several types, and Type otherwise. Exclusive bounds set as values are
+// written as numbers instead of the draft-04 boolean flags, a non-nil
+// empty Required as an empty array, and TupleItems as an items array.
+func (t *Type) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type             interface{} `json:"type,omitempty"`
+		ExclusiveMaximum interface{} `json:"exclusiveMaximum,omitempty"`
+		ExclusiveMinimum interface{} `json:"exclusiveMinimum,omitempty"`
+		Required         interface{} `json:"required,omitempty"`
+		Items            interface{} `json:"items,omitempty"`
+		*plainType
+	}{t.jsonType(), t.jsonExclusiveMaximum(), t.jsonExclusiveMinimum(), t.jsonRequired(), t.jsonItems(), (*plainType)(t)})
+}
+
+// MarshalJSON writes the root type along with the definitions, under
+// $defs for drafts since 2019-09, and the root $id as id for draft-04.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	var definitions, defs Definitions
+	if s.Draft.definitionsKeyword() == "$defs" {
+		defs = s.Definitions
+	} else {
+		definitions = s.Definitions
+	}
+
+	// draft-04 names the identifier id
+	var id, legacyID string
+	if s.Type != nil {
+		id = s.Type.ID
+	}
+	if s.Draft == Draft04 {
+		id, legacyID = "", id
+	}
+
+	return json.Marshal(struct {
+		ID               string      `json:"$id,omitempty"`
+		LegacyID         string      `json:"id,omitempty"`
+		Type             interface{} `json:"type,omitempty"`
+		ExclusiveMaximum interface{} `json:"exclusiveMaximum,omitempty"`
+		ExclusiveMinimum interface{} `json:"exclusiveMinimum,omitempty"`
+		Required         interface{} `json:"required,omitempty"`
+		Items            interface{} `json:"items,omitempty"`
+		*plainType
+		Definitions Definitions `json:"definitions,omitempty"`
+		Defs        Definitions `json:"$defs,omitempty"`
+	}{id, legacyID, s.Type.jsonType(), s.Type.jsonExclusiveMaximum(), s.Type.jsonExclusiveMinimum(), s.Type.jsonRequired(), s.Type.jsonItems(), (*plainType)(s.Type), definitions, defs})
 }
 
-func newReference(typ string) *Type {
-	return &Type{Ref: fmt.Sprintf("#/definitions/%s", typ)}
+func (t *Type) jsonType() interface{} {
+	switch {
+	case t == nil:
+		return nil
+	case len(t.Types) > 0:
+		return t.Types
+	case t.Type != "":
+		return t.Type
+	}
+
+	return nil
+}
+
+func (t *Type) jsonRequired() interface{} {
+	if t == nil || t.Required == nil {
+		return nil
+	}
+
+	return t.Required
+}
+
+func (t *Type) jsonItems() interface{} {
+	switch {
+	case t == nil:
+		return nil
+	case t.TupleItems != nil:
+		return t.TupleItems
+	case t.Items != nil:
+		return t.Items
+	}
+
+	return nil
+}
+
+func (t *Type) jsonExclusiveMaximum() interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return exclusiveBound(t.ExclusiveMaximum, t.ExclusiveMaximumValue)
+}
+
+func (t *Type) jsonExclusiveMinimum() interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return exclusiveBound(t.ExclusiveMinimum, t.ExclusiveMinimumValue)
+}
+
+// exclusiveBound returns the number of a draft-06 exclusive bound, or the
+// draft-04 flag when only that is set.
+func exclusiveBound(flag bool, value *float64) interface{} {
+	switch {
+	case value != nil:
+		return *value
+	case flag:
+		return true
+	}
+
+	return nil
+}
+
+// JSON Pointer token escaping, RFC 6901 section 4
+var (
+	jsonPointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+func escapeJSONPointer(token string) string {
+	return jsonPointerEscaper.Replace(token)
+}
+
+func unescapeJSONPointer(token string) string {
+	return jsonPointerUnescaper.Replace(token)
+}
+
+// definitionName returns the name of the definition ref refers to,
+// ignoring the base URI of namespaced references.
+func definitionName(ref string) (string, bool) {
+	if idx := strings.Index(ref, "#"); idx > 0 {
+		ref = ref[idx:]
+	}
+
+	for _, prefix := range []string{Draft07.definitionsPrefix(), Draft202012.definitionsPrefix()} {
+		if strings.HasPrefix(ref, prefix) {
+			return unescapeJSONPointer(strings.TrimPrefix(ref, prefix)), true
+		}
+	}
+
+	return "", false
 }
 
 func newType(typ string) *Type {
@@ -81,3 +355,394 @@ func newType(typ string) *Type {
 		Dependencies: map[string]*Type{},
 	}
 }
+
+// intPtr returns a pointer to i, for keywords distinguishing unset from 0.
+func intPtr(i int) *int {
+	return &i
+}
+
+// floatPtr is like intPtr for number keywords.
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+// SetProperty sets the schema of the named property, creating the
+// properties map if needed.
+func (t *Type) SetProperty(name string, typ *Type) {
+	if t.Properties == nil {
+		t.Properties = map[string]*Type{}
+	}
+
+	t.Properties[name] = typ
+}
+
+// RemoveProperty removes the named property and its required entry.
+func (t *Type) RemoveProperty(name string) {
+	delete(t.Properties, name)
+	t.removeRequired(name)
+}
+
+func (t *Type) removeRequired(name string) {
+	for i, required := range t.Required {
+		if required == name {
+			t.Required = append(t.Required[:i:i], t.Required[i+1:]...)
+			break
+		}
+	}
+
+	// an empty but non-nil list would be written as "required": []
+	if len(t.Required) == 0 {
+		t.Required = nil
+	}
+}
+
+// AddRequired appends names to the required properties in the given
+// order, skipping names already required.
+func (t *Type) AddRequired(names ...string) {
+	for _, name := range names {
+		if !t.isRequired(name) {
+			t.Required = append(t.Required, name)
+		}
+	}
+}
+
+func (t *Type) isRequired(name string) bool {
+	for _, required := range t.Required {
+		if required == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Equal reports whether t and other describe the same schema.
+func (t *Type) Equal(other *Type) bool {
+	return reflect.DeepEqual(t, other)
+}
+
+// JSONPointer returns the sub-schema addressed by the path of keywords and
+// names below t, e.g. ("properties", "id"), or nil if there is none.
+func (t *Type) JSONPointer(path ...string) *Type {
+	current := t
+
+	for i := 0; i < len(path) && current != nil; i++ {
+		switch path[i] {
+		case "items":
+			if current.TupleItems == nil {
+				current = current.Items
+				continue
+			}
+		case "additionalItems":
+			current = current.AdditionalItems
+			continue
+		case "not":
+			current = current.Not
+			continue
+		}
+
+		if i+1 == len(path) {
+			return nil
+		}
+
+		keyword, token := path[i], path[i+1]
+		i++
+
+		switch keyword {
+		case "properties":
+			current = current.Properties[token]
+		case "patternProperties":
+			current = current.PatternProperties[token]
+		case "dependencies":
+			current = current.Dependencies[token]
+		case "definitions":
+			current = current.Definitions[token]
+		case "items":
+			current = typeAt(current.TupleItems, token)
+		case "prefixItems":
+			current = typeAt(current.PrefixItems, token)
+		case "allOf":
+			current = typeAt(current.AllOf, token)
+		case "anyOf":
+			current = typeAt(current.AnyOf, token)
+		case "oneOf":
+			current = typeAt(current.OneOf, token)
+		default:
+			return nil
+		}
+	}
+
+	return current
+}
+
+// JSONPointer returns the sub-schema addressed by the path of keywords and
+// names below the root, e.g. ("definitions", "User"), or nil if there is none.
+func (s *Schema) JSONPointer(path ...string) *Type {
+	if len(path) >= 2 && (path[0] == "definitions" || path[0] == "$defs") {
+		return s.Definitions[path[1]].JSONPointer(path[2:]...)
+	}
+
+	return s.Type.JSONPointer(path...)
+}
+
+func typeAt(types []*Type, token string) *Type {
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || idx >= len(types) {
+		return nil
+	}
+
+	return types[idx]
+}
+
+// RemoveDefaults recursively clears default values, e.g. after reflecting
+// a populated instance.
+func (t *Type) RemoveDefaults() {
+	t.walk(func(typ *Type) {
+		typ.Default = nil
+	})
+}
+
+// RemoveDefaults recursively clears default values of the root type and
+// all definitions.
+func (s *Schema) RemoveDefaults() {
+	if s.Type != nil {
+		s.Type.RemoveDefaults()
+	}
+
+	for _, def := range s.Definitions {
+		def.RemoveDefaults()
+	}
+}
+
+// UsedDefinitions returns the sorted names of definitions transitively
+// referenced from the root type.
+func (s *Schema) UsedDefinitions() []string {
+	used := map[string]bool{}
+
+	var visit func(*Type)
+	visit = func(t *Type) {
+		t.walk(func(typ *Type) {
+			name, ok := definitionName(typ.Ref)
+			if !ok || used[name] {
+				return
+			}
+
+			used[name] = true
+			visit(s.Definitions[name])
+		})
+	}
+	visit(s.Type)
+
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// EmbedInto sets the root type of s as the named property of the root of
+// parent and merges its definitions into parent's, so references keep
+// resolving. Like Reflector.ReflectInto it fails without changing parent
+// when a definition of the same name but a different structure is already
+// registered, and it fails for schemas of different drafts, whose
+// references point to different keywords.
+func (s *Schema) EmbedInto(parent *Schema, propertyName string) error {
+	if s.Draft != parent.Draft {
+		return fmt.Errorf("jsonschema: cannot embed a schema of draft %s into one of draft %s", s.Draft.URI(), parent.Draft.URI())
+	}
+
+	for name, def := range s.Definitions {
+		if existing, ok := parent.Definitions[name]; ok && !existing.Equal(def) {
+			return fmt.Errorf("jsonschema: conflicting definitions of %q", name)
+		}
+	}
+
+	root := *s.Type
+	root.Version = ""
+	parent.Type.SetProperty(propertyName, &root)
+
+	if len(s.Definitions) == 0 {
+		return nil
+	}
+
+	if parent.Definitions == nil {
+		parent.Definitions = Definitions{}
+	}
+
+	for name, def := range s.Definitions {
+		parent.Definitions[name] = def
+	}
+
+	return nil
+}
+
+// Prune removes definitions not transitively referenced from the root type.
+func (s *Schema) Prune() {
+	used := map[string]bool{}
+	for _, name := range s.UsedDefinitions() {
+		used[name] = true
+	}
+
+	for name := range s.Definitions {
+		if !used[name] {
+			delete(s.Definitions, name)
+		}
+	}
+}
+
+// walk calls fn for t and every nested sub-schema.
+func (t *Type) walk(fn func(*Type)) {
+	t.walkPath("", func(_ string, typ *Type) {
+		fn(typ)
+	})
+}
+
+// walkPath is like walk but also passes the JSON Pointer of every
+// sub-schema relative to t, visiting them in a stable order.
+func (t *Type) walkPath(path string, fn func(string, *Type)) {
+	if t == nil {
+		return
+	}
+
+	fn(path, t)
+
+	for _, sub := range []struct {
+		keyword string
+		typ     *Type
+	}{
+		{"additionalItems", t.AdditionalItems},
+		{"items", t.Items},
+		{"not", t.Not},
+		{"media", t.Media},
+		{"if", t.If},
+		{"then", t.Then},
+		{"else", t.Else},
+		{"propertyNames", t.PropertyNames},
+	} {
+		sub.typ.walkPath(path+"/"+sub.keyword, fn)
+	}
+
+	for _, sub := range []struct {
+		keyword string
+		types   []*Type
+	}{
+		{"items", t.TupleItems},
+		{"prefixItems", t.PrefixItems},
+		{"allOf", t.AllOf},
+		{"anyOf", t.AnyOf},
+		{"oneOf", t.OneOf},
+	} {
+		for i, typ := range sub.types {
+			typ.walkPath(fmt.Sprintf("%s/%s/%d", path, sub.keyword, i), fn)
+		}
+	}
+
+	for _, sub := range []struct {
+		keyword string
+		types   map[string]*Type
+	}{
+		{"properties", t.Properties},
+		{"patternProperties", t.PatternProperties},
+		{"dependencies", t.Dependencies},
+		{"definitions", t.Definitions},
+	} {
+		names := make([]string, 0, len(sub.types))
+		for name := range sub.types {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			sub.types[name].walkPath(path+"/"+sub.keyword+"/"+escapeJSONPointer(name), fn)
+		}
+	}
+}
+
+// deepCopy returns a copy of t sharing no sub-schemas, slices or maps with
+// it, so either can be changed without affecting the other.
+func (t *Type) deepCopy() *Type {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+
+	for _, sub := range []**Type{
+		&c.AdditionalItems, &c.Items, &c.Not, &c.Media,
+		&c.If, &c.Then, &c.Else, &c.PropertyNames,
+	} {
+		*sub = (*sub).deepCopy()
+	}
+
+	for _, sub := range []*[]*Type{&c.TupleItems, &c.PrefixItems, &c.AllOf, &c.AnyOf, &c.OneOf} {
+		if *sub == nil {
+			continue
+		}
+
+		types := make([]*Type, len(*sub))
+		for i, typ := range *sub {
+			types[i] = typ.deepCopy()
+		}
+		*sub = types
+	}
+
+	for _, sub := range []*map[string]*Type{&c.Properties, &c.PatternProperties, &c.Dependencies} {
+		*sub = copyTypes(*sub)
+	}
+	c.Definitions = Definitions(copyTypes(c.Definitions))
+
+	if t.Required != nil {
+		c.Required = append([]string{}, t.Required...)
+	}
+	c.Types = append([]string(nil), t.Types...)
+	c.EnumVarNames = append([]string(nil), t.EnumVarNames...)
+	c.Enum = append([]interface{}(nil), t.Enum...)
+	c.Examples = append([]interface{}(nil), t.Examples...)
+	c.Links = append([]Link(nil), t.Links...)
+	c.AdditionalProperties = append(json.RawMessage(nil), t.AdditionalProperties...)
+
+	for _, f := range []**float64{
+		&c.MultipleOf, &c.Maximum, &c.Minimum,
+		&c.ExclusiveMaximumValue, &c.ExclusiveMinimumValue,
+	} {
+		if *f != nil {
+			v := **f
+			*f = &v
+		}
+	}
+
+	for _, n := range []**int{&c.MaxLength, &c.MinLength, &c.MaxItems, &c.MinItems} {
+		if *n != nil {
+			v := **n
+			*n = &v
+		}
+	}
+
+	if t.Discriminator != nil {
+		discriminator := *t.Discriminator
+		if t.Discriminator.Mapping != nil {
+			discriminator.Mapping = make(map[string]string, len(t.Discriminator.Mapping))
+			for value, ref := range t.Discriminator.Mapping {
+				discriminator.Mapping[value] = ref
+			}
+		}
+		c.Discriminator = &discriminator
+	}
+
+	return &c
+}
+
+func copyTypes(types map[string]*Type) map[string]*Type {
+	if types == nil {
+		return nil
+	}
+
+	c := make(map[string]*Type, len(types))
+	for name, typ := range types {
+		c[name] = typ.deepCopy()
+	}
+
+	return c
+}
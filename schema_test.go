@@ -1,6 +1,7 @@
 package jsonschema
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,7 +13,326 @@ import (
 //}
 
 func TestNewReference(t *testing.T) {
-	ref := newReference(tTypeString)
+	ref := (&Reflector{}).newReference(tTypeString)
 	require.NotNil(t, ref)
 	assert.Equal(t, "#/definitions/string", ref.Ref)
+
+	ref = (&Reflector{Draft: Draft202012}).newReference(tTypeString)
+	require.NotNil(t, ref)
+	assert.Equal(t, "#/$defs/string", ref.Ref)
+}
+
+func TestRemoveDefaults(t *testing.T) {
+	a := assert.New(t)
+
+	schema := Reflect(TestUser{
+		SomeBaseType: SomeBaseType{
+			Grandfather: GrandfatherType{FamilyName: "family"},
+		},
+		ID:      666,
+		Name:    "some name",
+		Friends: []int{1, 2, 3},
+		Tags:    map[string]interface{}{"tag": "value"},
+		Age:     42,
+		Email:   "some@email.com",
+	})
+
+	schema.RemoveDefaults()
+
+	var defaults []interface{}
+	collect := func(typ *Type) {
+		if typ.Default != nil {
+			defaults = append(defaults, typ.Default)
+		}
+	}
+
+	schema.Type.walk(collect)
+	for _, def := range schema.Definitions {
+		def.walk(collect)
+	}
+
+	a.Empty(defaults)
+}
+
+func TestSchemaMarshalJSON(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(TestUser{})
+
+	data, err := json.Marshal(schema)
+	r.NoError(err)
+
+	var keys map[string]interface{}
+	r.NoError(json.Unmarshal(data, &keys))
+	a.Equal(tTypeObject, keys["type"])
+	a.Equal(Version, keys["$schema"])
+	a.Contains(keys, "properties")
+	a.Contains(keys, "definitions")
+
+	valueData, err := json.Marshal(*schema)
+	r.NoError(err)
+	a.JSONEq(string(data), string(valueData))
+}
+
+type Family struct {
+	Father  GrandfatherType `json:"father"`
+	Members []SomeStruct    `json:"members"`
+}
+
+func TestUsedDefinitions(t *testing.T) {
+	schema := Reflect(Family{})
+
+	assert.Equal(t, []string{"ColorPicker", "GrandfatherType", "SomeStruct", "TextArea"}, schema.UsedDefinitions())
+}
+
+func TestPrune(t *testing.T) {
+	a := assert.New(t)
+
+	schema := Reflect(Family{})
+	schema.Definitions["Orphan"] = newType(tTypeObject)
+
+	schema.Prune()
+
+	a.NotContains(schema.Definitions, "Orphan")
+	a.Contains(schema.Definitions, "GrandfatherType")
+	a.Contains(schema.Definitions, "SomeStruct")
+	a.Contains(schema.Definitions, "ColorPicker")
+	a.Contains(schema.Definitions, "TextArea")
+}
+
+type Catalog struct {
+	Name string `json:"name"`
+}
+
+func TestEmbedInto(t *testing.T) {
+	t.Run("EmbedInto_sets_RootAsProperty", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		parent := Reflect(Catalog{})
+		schema := Reflect(GrandfatherType{})
+		r.NoError(schema.EmbedInto(parent, "grandfather"))
+
+		r.Contains(parent.Properties, "grandfather")
+		grandfather := parent.Properties["grandfather"]
+		a.Equal(tTypeObject, grandfather.Type)
+		a.Contains(grandfather.Properties, "family_name")
+		a.Empty(grandfather.Version)
+		a.Equal(Version, schema.Version)
+		a.Empty(parent.Definitions)
+	})
+	t.Run("EmbedInto_merges_Definitions", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		parent := Reflect(Catalog{})
+		parent.Definitions["Existing"] = &Type{Type: tTypeString}
+		r.NoError(Reflect(Family{}).EmbedInto(parent, "family"))
+
+		r.Contains(parent.Properties, "family")
+		a.Equal("#/definitions/GrandfatherType", parent.Properties["family"].Properties["father"].Ref)
+		a.Contains(parent.Definitions, "Existing")
+		a.Contains(parent.Definitions, "GrandfatherType")
+		a.Contains(parent.Definitions, "SomeStruct")
+	})
+	t.Run("EmbedInto_resolves_ReferencesAfterMarshaling", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		parent := (&Reflector{Draft: Draft202012}).Reflect(Catalog{})
+		r.NoError((&Reflector{Draft: Draft202012}).Reflect(Family{}).EmbedInto(parent, "family"))
+
+		data, err := json.Marshal(parent)
+		r.NoError(err)
+		a.Contains(string(data), `"$defs":{"ColorPicker":`)
+
+		a.NoError(parent.ValidateJSON([]byte(`{"name":"doe","family":{"father":{"family_name":"Doe"},"members":[]}}`)))
+		a.Error(parent.ValidateJSON([]byte(`{"name":"doe","family":{"father":{"family_name":1},"members":[]}}`)))
+	})
+	t.Run("EmbedInto_rejects_DifferentDrafts", func(t *testing.T) {
+		a := assert.New(t)
+
+		parent := Reflect(Catalog{})
+		err := (&Reflector{Draft: Draft202012}).Reflect(Family{}).EmbedInto(parent, "family")
+
+		a.Error(err)
+		a.NotContains(parent.Properties, "family")
+	})
+	t.Run("EmbedInto_rejects_ConflictingDefinitions", func(t *testing.T) {
+		a := assert.New(t)
+
+		parent := Reflect(Catalog{})
+		parent.Definitions["GrandfatherType"] = &Type{Type: tTypeString}
+		err := Reflect(Family{}).EmbedInto(parent, "family")
+
+		a.EqualError(err, `jsonschema: conflicting definitions of "GrandfatherType"`)
+		a.NotContains(parent.Properties, "family")
+		a.NotContains(parent.Definitions, "SomeStruct")
+	})
+}
+
+func TestPropertyHelpers(t *testing.T) {
+	a := assert.New(t)
+
+	typ := &Type{Type: tTypeObject}
+
+	typ.SetProperty("id", &Type{Type: tTypeInteger})
+	typ.SetProperty("name", &Type{Type: tTypeString})
+	typ.SetProperty("email", &Type{Type: tTypeString, Format: "email"})
+	typ.AddRequired("name", "id")
+	typ.AddRequired("id", "email")
+
+	a.Len(typ.Properties, 3)
+	a.Equal(tTypeInteger, typ.Properties["id"].Type)
+	a.Equal([]string{"name", "id", "email"}, typ.Required)
+
+	typ.SetProperty("id", &Type{Type: tTypeString})
+	a.Equal(tTypeString, typ.Properties["id"].Type)
+
+	typ.RemoveProperty("id")
+	a.NotContains(typ.Properties, "id")
+	a.Equal([]string{"name", "email"}, typ.Required)
+}
+
+func TestTypeEqual(t *testing.T) {
+	a := assert.New(t)
+
+	a.True(Reflect(GrandfatherType{}).Type.Equal(Reflect(GrandfatherType{}).Type))
+	a.False(Reflect(GrandfatherType{}).Type.Equal(Reflect(SomeStruct{}).Type))
+	a.False(Reflect(GrandfatherType{}).Type.Equal(nil))
+}
+
+func TestSanitizeRefs(t *testing.T) {
+	a := assert.New(t)
+
+	name := "github.com/bmartynov/jsonschema.Some~Type"
+
+	ref := (&Reflector{}).newReference(name)
+	a.Equal("#/definitions/github.com/bmartynov/jsonschema.Some~Type", ref.Ref)
+
+	ref = (&Reflector{SanitizeRefs: true}).newReference(name)
+	a.Equal("#/definitions/github.com~1bmartynov~1jsonschema.Some~0Type", ref.Ref)
+
+	schema := &Schema{
+		Type: &Type{
+			Type:       tTypeObject,
+			Properties: map[string]*Type{"some": ref},
+		},
+		Definitions: Definitions{
+			name:     newType(tTypeObject),
+			"Orphan": newType(tTypeObject),
+		},
+	}
+
+	a.Equal([]string{name}, schema.UsedDefinitions())
+
+	schema.Prune()
+	a.Contains(schema.Definitions, name)
+	a.NotContains(schema.Definitions, "Orphan")
+}
+
+func TestJSONPointer(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(Family{})
+
+	familyName := schema.JSONPointer("definitions", "GrandfatherType", "properties", "family_name")
+	r.NotNil(familyName)
+	a.Equal(tTypeString, familyName.Type)
+
+	items := schema.JSONPointer("properties", "members", "items")
+	r.NotNil(items)
+	a.Equal("#/definitions/SomeStruct", items.Ref)
+
+	a.True(schema.Type == schema.JSONPointer())
+	a.Nil(schema.JSONPointer("properties", "missing"))
+	a.Nil(schema.JSONPointer("definitions", "Missing", "properties", "id"))
+	a.Nil(schema.JSONPointer("properties"))
+	a.Nil(schema.JSONPointer("unknown", "keyword"))
+
+	typ := &Type{OneOf: []*Type{{Type: tTypeString}, {Type: tTypeInteger}}}
+	a.Equal(tTypeInteger, typ.JSONPointer("oneOf", "1").Type)
+	a.Nil(typ.JSONPointer("oneOf", "2"))
+}
+
+func TestDraft(t *testing.T) {
+	t.Run("Reflect_writes_SchemaURIPerDraft", func(t *testing.T) {
+		a := assert.New(t)
+
+		for draft, uri := range map[Draft]string{
+			Draft04:     "http://json-schema.org/draft-04/schema#",
+			Draft06:     "http://json-schema.org/draft-06/schema#",
+			Draft07:     "http://json-schema.org/draft-07/schema#",
+			Draft201909: "https://json-schema.org/draft/2019-09/schema",
+			Draft202012: "https://json-schema.org/draft/2020-12/schema",
+		} {
+			schema := (&Reflector{Draft: draft}).Reflect(&Family{})
+			a.Equal(uri, schema.Version)
+		}
+	})
+	t.Run("Reflect_references_DefsSince2020_12", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := (&Reflector{Draft: Draft202012}).Reflect(&Family{})
+		a.Equal("#/$defs/GrandfatherType", schema.Properties["father"].Ref)
+		a.Contains(schema.UsedDefinitions(), "GrandfatherType")
+
+		data, err := json.Marshal(schema)
+		r.NoError(err)
+		a.Contains(string(data), `"$defs":{"ColorPicker":`)
+		a.NotContains(string(data), `"definitions"`)
+	})
+	t.Run("Reflect_references_DefinitionsOrDefsPerDraft", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		for draft, keyword := range map[Draft]string{
+			Draft04:     "definitions",
+			Draft06:     "definitions",
+			Draft07:     "definitions",
+			Draft201909: "$defs",
+			Draft202012: "$defs",
+		} {
+			schema := (&Reflector{Draft: draft}).Reflect(&Family{})
+			a.Equal("#/"+keyword+"/GrandfatherType", schema.Properties["father"].Ref)
+			a.NotNil(schema.JSONPointer(keyword, "GrandfatherType"))
+
+			data, err := json.Marshal(schema)
+			r.NoError(err)
+			a.Contains(string(data), `"`+keyword+`":{`)
+		}
+	})
+	t.Run("Reflect_namespaces_RefsWithBaseSchemaID", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := (&Reflector{BaseSchemaID: "https://example.com/family.json"}).Reflect(&Family{})
+		a.Equal("https://example.com/family.json", schema.ID)
+		a.Equal("https://example.com/family.json#/definitions/GrandfatherType", schema.Properties["father"].Ref)
+		a.Contains(schema.UsedDefinitions(), "GrandfatherType")
+
+		data, err := json.Marshal(schema)
+		r.NoError(err)
+		a.Contains(string(data), `"$id":"https://example.com/family.json"`)
+	})
+	t.Run("MarshalJSON_writes_IDForDraft04", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := (&Reflector{Draft: Draft04, BaseSchemaID: "https://example.com/family.json"}).Reflect(&Family{})
+
+		data, err := json.Marshal(schema)
+		r.NoError(err)
+		a.Contains(string(data), `"id":"https://example.com/family.json"`)
+		a.NotContains(string(data), `"$id"`)
+	})
+	t.Run("Draft_orders_ByPublication", func(t *testing.T) {
+		a := assert.New(t)
+
+		a.Equal(Draft(0), Draft07)
+		a.True(Draft04 < Draft06 && Draft06 < Draft07 && Draft07 < Draft201909 && Draft201909 < Draft202012)
+	})
 }
@@ -1,23 +1,53 @@
 package jsonschema
 
 import (
+	"fmt"
 	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
-	tagName     = "name"
-	tagNameJson = "json"
-	tagTitle    = "title"
-	tagRequired = "required"
-	tagIgnore   = "ignore"
+	tagNamespace = "jsonschema"
+
+	tagName        = "name"
+	tagNameJson    = "json"
+	tagTitle       = "title"
+	tagDescription = "description"
+	tagRequired    = "required"
+	tagIgnore      = "ignore"
+	tagReadOnly    = "readOnly"
+	tagWriteOnly   = "writeOnly"
+	tagConst       = "const"
+	tagTypes       = "types"
+	tagEnum        = "enum"
+	tagEnumNames   = "enumNames"
+	tagComment     = "comment"
+	tagExamples    = "examples"
+	tagDefault     = "default"
+
+	// embedded struct specific
+	tagEmbeddedPrefix = "prefix"
+
+	tagListSeparator = "|"
+
+	// property name tag options
+	tagOptionOmitEmpty = "omitempty"
+	tagOptionString    = "string"
+
+	// byte slices and arrays
+	tagBinary = "binary"
 
 	// string
 	tagStringMinLength = "minLength"
 	tagStringMaxLength = "maxLength"
+	tagStringMinLen    = "minlen"
+	tagStringMaxLen    = "maxlen"
 	tagStringFormat    = "format"
+	tagStringFormats   = "formats"
+	tagStringPattern   = "pattern"
 
 	// number
 	tagNumberMultipleOf       = "multipleOf"
@@ -25,6 +55,11 @@ const (
 	tagNumberMaximum          = "maximum"
 	tagNumberExclusiveMaximum = "exclusiveMaximum"
 	tagNumberExclusiveMinimum = "exclusiveMinimum"
+	tagNumberMin              = "min"
+	tagNumberMax              = "max"
+
+	// object
+	tagObjectPropertyNames = "propertyNames"
 
 	// array
 	tagArrayMinItems    = "minItems"
@@ -59,35 +94,125 @@ func parseExpression(t tags) *expression {
 }
 
 type tags struct {
-	name     string
-	title    string
-	required bool
-	ignored  bool
+	name        string
+	title       string
+	description string
+	required    bool
+	ignored     bool
+	omitEmpty   bool
+	asString    bool
+	noBinary    bool // bytes described as an array of integers
+	binary      bool // byte arrays described as base64
+	readOnly    bool
+	writeOnly   bool
+	constant    *string // raw value, coerced to the field type when applied
+	types       []string
+	enum        []string // raw values, coerced to the field type when applied
+	enumNames   []string
+	comment     string
+	examples    []string // raw values, coerced to the field type when applied
+	defaultRaw  *string  // raw value, coerced to the field type when applied
+	// embedded struct specific
+	prefix string
 	// string specific
-	minLength int
-	maxLength int
+	minLength *int
+	maxLength *int
 	format    string
+	formats   []string
+	pattern   string
 	// number specific
-	multipleOf       int
-	minimum          int
-	maximum          int
-	exclusiveMaximum bool
-	exclusiveMinimum bool
+	multipleOf            *float64
+	minimum               *float64
+	maximum               *float64
+	exclusiveMaximum      bool
+	exclusiveMinimum      bool
+	exclusiveMaximumValue *float64
+	exclusiveMinimumValue *float64
+	// object specific
+	propertyNames string
 	// array specific
-	minItems    int
-	maxItems    int
+	minItems    *int
+	maxItems    *int
 	uniqueItems bool
 
 	showIf string
 	hideIf string
 }
 
-func parseTags(tag reflect.StructTag) tags {
+// tagLookup resolves keyword values declared inside the jsonschema tag
+// (`jsonschema:"minimum=1"`), whose name may be configured by
+// Reflector.StructTagName, or as standalone struct tags (`minimum:"1"`)
+// when Reflector.StandaloneTags is set.
+// Keywords inside the jsonschema tag match case-insensitively, so
+// `jsonschema:"readonly"` reads as readOnly. A pattern takes the rest of
+// the jsonschema tag, commas included, so it must come last.
+type tagLookup struct {
+	tag        reflect.StructTag
+	keywords   map[string]string
+	standalone bool
+}
+
+func newTagLookup(tag reflect.StructTag, namespace string, standalone bool) tagLookup {
+	keywords := map[string]string{}
+
+	parts := strings.Split(tag.Get(namespace), ",")
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		key := strings.ToLower(kv[0])
+		if len(kv) == 1 {
+			keywords[key] = "true" // bare flag, e.g. `jsonschema:"required"`
+			continue
+		}
+
+		// a pattern may hold commas, as in {2,3}, so it runs to the end
+		if key == strings.ToLower(tagStringPattern) {
+			keywords[key] = strings.Join(append([]string{kv[1]}, parts[i+1:]...), ",")
+			break
+		}
+
+		keywords[key] = kv[1]
+	}
+
+	return tagLookup{tag: tag, keywords: keywords, standalone: standalone}
+}
+
+// get returns the value of the first keyword present, so aliases can be
+// passed after the canonical keyword.
+func (l tagLookup) get(keys ...string) string {
+	value, _ := l.lookup(keys...)
+	return value
+}
+
+// lookup is like get but also reports whether any of the keywords is present.
+func (l tagLookup) lookup(keys ...string) (string, bool) {
+	for _, key := range keys {
+		if value, ok := l.keywords[strings.ToLower(key)]; ok {
+			return value, true
+		}
+	}
+
+	if l.standalone {
+		for _, key := range keys {
+			if value, ok := l.tag.Lookup(key); ok {
+				return value, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func (r *Reflector) parseTags(tag reflect.StructTag) tags {
 	t := tags{}
 
+	parts := strings.Split(tag.Get(r.propertyNameTag()), ",")
+
 	var ok bool
 	if t.name, ok = tag.Lookup(tagName); !ok {
-		parts := strings.Split(tag.Get(tagNameJson), ",")
 		if parts[0] == "-" {
 			t.ignored = true
 			return t
@@ -95,57 +220,280 @@ func parseTags(tag reflect.StructTag) tags {
 		t.name = parts[0]
 	}
 
-	t.title = tag.Get(tagTitle)
-	t.ignored, _ = strconv.ParseBool(tag.Get(tagIgnore))
-	t.required, _ = strconv.ParseBool(tag.Get(tagRequired))
+	t.omitEmpty = hasOption(parts[1:], tagOptionOmitEmpty)
+	t.asString = hasOption(parts[1:], tagOptionString)
+
+	lookup := newTagLookup(tag, r.keywordsTag(), r.StandaloneTags)
+
+	t.title = lookup.get(tagTitle)
+	t.description = lookup.get(tagDescription)
+	t.prefix = lookup.get(tagEmbeddedPrefix)
+	t.ignored, _ = strconv.ParseBool(lookup.get(tagIgnore))
+	if _, ok := lookup.keywords["-"]; ok {
+		t.ignored = true // `jsonschema:"-"` leaves the field out like `json:"-"`
+	}
+	// fields always present in the output are required unless the tag says otherwise
+	t.required = r.RequiredByDefault || !t.omitEmpty
+	if required, ok := lookup.lookup(tagRequired); ok {
+		t.required, _ = strconv.ParseBool(required)
+	}
+	t.readOnly, _ = strconv.ParseBool(lookup.get(tagReadOnly))
+	t.writeOnly, _ = strconv.ParseBool(lookup.get(tagWriteOnly))
+	t.noBinary = lookup.get(tagBinary) == "false"
+	t.binary = lookup.get(tagBinary) == "true"
+	t.types = splitList(lookup.get(tagTypes))
+	t.enum = splitList(lookup.get(tagEnum))
+	t.enumNames = splitList(lookup.get(tagEnumNames))
+	t.comment = lookup.get(tagComment)
+	t.examples = splitList(lookup.get(tagExamples))
+	if constant, ok := lookup.lookup(tagConst); ok {
+		t.constant = &constant
+	}
+	if defaultRaw, ok := lookup.lookup(tagDefault); ok {
+		t.defaultRaw = &defaultRaw
+	}
+	for _, key := range r.RequiredTagKeys {
+		if hasOption(strings.Split(tag.Get(key), ","), tagRequired) {
+			t.required = true
+		}
+	}
 
 	// string specific
-	t.minLength, _ = strconv.Atoi(tag.Get(tagStringMinLength))
-	t.maxLength, _ = strconv.Atoi(tag.Get(tagStringMaxLength))
-	t.format = tag.Get(tagStringFormat)
+	t.minLength = parseIntTag(lookup.get(tagStringMinLength, tagStringMinLen))
+	t.maxLength = parseIntTag(lookup.get(tagStringMaxLength, tagStringMaxLen))
+	t.format = lookup.get(tagStringFormat)
+	t.formats = splitList(lookup.get(tagStringFormats))
+	t.pattern = lookup.get(tagStringPattern)
 
 	// number specific
-	t.multipleOf, _ = strconv.Atoi(tag.Get(tagNumberMultipleOf))
-	t.minimum, _ = strconv.Atoi(tag.Get(tagNumberMinimum))
-	t.maximum, _ = strconv.Atoi(tag.Get(tagNumberMaximum))
-	t.exclusiveMinimum, _ = strconv.ParseBool(tag.Get(tagNumberExclusiveMinimum))
-	t.exclusiveMaximum, _ = strconv.ParseBool(tag.Get(tagNumberExclusiveMaximum))
+	t.multipleOf = parseFloatTag(lookup.get(tagNumberMultipleOf))
+	t.minimum = parseFloatTag(lookup.get(tagNumberMinimum, tagNumberMin))
+	t.maximum = parseFloatTag(lookup.get(tagNumberMaximum, tagNumberMax))
+	// exclusive bounds are numbers since draft-06 and flags before
+	exclusiveMinimum := lookup.get(tagNumberExclusiveMinimum)
+	if t.exclusiveMinimumValue = parseFloatTag(exclusiveMinimum); t.exclusiveMinimumValue == nil {
+		t.exclusiveMinimum, _ = strconv.ParseBool(exclusiveMinimum)
+	}
+	exclusiveMaximum := lookup.get(tagNumberExclusiveMaximum)
+	if t.exclusiveMaximumValue = parseFloatTag(exclusiveMaximum); t.exclusiveMaximumValue == nil {
+		t.exclusiveMaximum, _ = strconv.ParseBool(exclusiveMaximum)
+	}
+
+	// object specific
+	t.propertyNames = lookup.get(tagObjectPropertyNames)
 
 	// array specific
-	t.minItems, _ = strconv.Atoi(tag.Get(tagArrayMinItems))
-	t.maxItems, _ = strconv.Atoi(tag.Get(tagArrayMaxItems))
-	t.uniqueItems, _ = strconv.ParseBool(tag.Get(tagArrayUniqueItems))
+	t.minItems = parseIntTag(lookup.get(tagArrayMinItems))
+	t.maxItems = parseIntTag(lookup.get(tagArrayMaxItems))
+	t.uniqueItems, _ = strconv.ParseBool(lookup.get(tagArrayUniqueItems))
 
 	// expression
-	t.showIf = tag.Get(tagConditionShowIf)
-	t.hideIf = tag.Get(tagConditionHideIf)
+	t.showIf = lookup.get(tagConditionShowIf)
+	t.hideIf = lookup.get(tagConditionHideIf)
 
 	return t
 }
 
+// parseIntTag parses an integer keyword, returning nil when it is unset
+// or malformed so it is left out of the schema.
+func parseIntTag(value string) *int {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return nil
+	}
+
+	return &i
+}
+
+// parseFloatTag is like parseIntTag for number keywords.
+func parseFloatTag(value string) *float64 {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &f
+}
+
+// splitList splits a tag value listing several values, e.g. `email|uri`.
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, tagListSeparator)
+}
+
+// coerceTagValue converts a raw tag value to a value valid for dst.
+// Go duration literals such as 1m30s become ISO 8601 durations on
+// duration formatted strings.
+func coerceTagValue(dst *Type, raw string) (interface{}, bool) {
+	if dst.Type == tTypeString && dst.Format == "duration" {
+		if d, err := time.ParseDuration(raw); err == nil {
+			return formatISODuration(d), true
+		}
+	}
+
+	return coerceValue(dst.Type, raw)
+}
+
+// mustCoerceTagValue is like coerceTagValue but panics on values not of
+// the type of dst, as mistyped tags are programming errors. Values for
+// schemas without a type, such as references, are kept raw.
+func mustCoerceTagValue(dst *Type, keyword, raw string) interface{} {
+	if dst.Type == "" {
+		return raw
+	}
+
+	value, ok := coerceTagValue(dst, raw)
+	if !ok {
+		panic(fmt.Sprintf("jsonschema: invalid %s value %q for type %s", keyword, raw, dst.Type))
+	}
+
+	return value
+}
+
+// coerceValue converts a raw tag value to a value of the JSON type typ.
+func coerceValue(typ string, raw string) (interface{}, bool) {
+	switch typ {
+	case tTypeBoolean:
+		value, err := strconv.ParseBool(raw)
+		return value, err == nil
+	case tTypeInteger:
+		value, err := strconv.ParseInt(raw, 10, 64)
+		return value, err == nil
+	case tTypeNumber:
+		value, err := strconv.ParseFloat(raw, 64)
+		return value, err == nil
+	case tTypeString:
+		return raw, true
+	}
+
+	return nil, false
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+
+	return false
+}
+
 func applyValidation(dst *Type, t tags) {
+	if len(t.types) > 0 {
+		dst.Type = t.types[0]
+		dst.Types = t.types
+	}
+
+	if t.constant != nil {
+		dst.Const = mustCoerceTagValue(dst, tagConst, *t.constant)
+	}
+
+	for _, raw := range t.enum {
+		dst.Enum = append(dst.Enum, mustCoerceTagValue(dst, tagEnum, raw))
+	}
+
 	switch dst.Type {
 	case tTypeString:
-		dst.MinLength = t.minLength
-		dst.MaxLength = t.maxLength
+		// keep lengths implied by the Go type unless the tag sets them
+		if t.minLength != nil {
+			dst.MinLength = t.minLength
+		}
+		if t.maxLength != nil {
+			dst.MaxLength = t.maxLength
+		}
 		if t.format != "" {
 			dst.Format = t.format
 		}
-	case tTypeNumber:
-		dst.MultipleOf = t.multipleOf
-		dst.Minimum = t.minimum
-		dst.Maximum = t.maximum
+		for _, format := range t.formats {
+			dst.AnyOf = append(dst.AnyOf, &Type{Format: format})
+		}
+		if t.pattern != "" {
+			// a schema no validator can use is a bug in the tagged type
+			if _, err := regexp.Compile(t.pattern); err != nil {
+				panic(fmt.Sprintf("jsonschema: invalid pattern %q: %v", t.pattern, err))
+			}
+			dst.Pattern = t.pattern
+		}
+	case tTypeNumber, tTypeInteger:
+		if t.multipleOf != nil {
+			dst.MultipleOf = t.multipleOf
+		}
+		if t.minimum != nil {
+			dst.Minimum = t.minimum
+		}
+		if t.maximum != nil {
+			dst.Maximum = t.maximum
+		}
 		dst.ExclusiveMinimum = t.exclusiveMinimum
 		dst.ExclusiveMaximum = t.exclusiveMaximum
+		if t.exclusiveMinimumValue != nil {
+			dst.ExclusiveMinimumValue = t.exclusiveMinimumValue
+		}
+		if t.exclusiveMaximumValue != nil {
+			dst.ExclusiveMaximumValue = t.exclusiveMaximumValue
+		}
+	case tTypeObject:
+		if t.propertyNames != "" {
+			dst.PropertyNames = &Type{Pattern: t.propertyNames}
+
+			// a closed map allows only keys matching its pattern
+			if values, ok := dst.PatternProperties[".*"]; ok && len(dst.PatternProperties) == 1 && string(dst.AdditionalProperties) == "false" {
+				dst.PatternProperties = map[string]*Type{t.propertyNames: values}
+			}
+		}
 	case tTypeArray:
-		dst.MinItems = t.minItems
-		dst.MaxItems = t.maxItems
+		// keep fixed array lengths unless the tag sets them
+		if t.minItems != nil {
+			dst.MinItems = t.minItems
+		}
+		if t.maxItems != nil {
+			dst.MaxItems = t.maxItems
+		}
 		dst.UniqueItems = t.uniqueItems
 	}
 }
 
-func applyInfo(dst *Type, t tags) {
+func (r *Reflector) applyInfo(dst *Type, t tags) {
 	dst.Title = t.title
+	if t.description != "" {
+		dst.Description = t.description
+	}
+	dst.ReadOnly = t.readOnly
+	dst.WriteOnly = t.writeOnly
+	if len(t.enumNames) > 0 {
+		dst.EnumVarNames = t.enumNames
+	}
+	if t.comment != "" {
+		dst.Comment = t.comment
+	}
+	for _, raw := range t.examples {
+		// references have no type to coerce to, keep the raw value
+		value, ok := coerceTagValue(dst, raw)
+		if !ok {
+			value = raw
+		}
+		dst.Examples = append(dst.Examples, value)
+	}
+
+	if t.defaultRaw != nil {
+		// references have no type to coerce to, keep the raw value
+		value, ok := coerceTagValue(dst, *t.defaultRaw)
+		if !ok {
+			value = *t.defaultRaw
+		}
+		dst.Default = value
+	}
+
+	switch {
+	case r.SwapTitleDescription:
+		dst.Title, dst.Description = dst.Description, dst.Title
+	case r.DescriptionFromTitle && dst.Description == "":
+		dst.Description = dst.Title
+	}
 }
 
 func isIgnored(t tags) bool {
@@ -0,0 +1,755 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type aliasTagged struct {
+	Canonical float64 `json:"canonical" jsonschema:"minimum=1,maximum=10"`
+	Alias     float64 `json:"alias" jsonschema:"min=1,max=10"`
+	Name      string  `json:"name" jsonschema:"minlen=2,maxlen=20"`
+	Legacy    float64 `json:"legacy" min:"1" max:"10"`
+}
+
+func TestParseTags(t *testing.T) {
+	reflector := &Reflector{}
+	typ := reflect.TypeOf(aliasTagged{})
+
+	t.Run("ParseTags_accepts_MinMaxAliases", func(t *testing.T) {
+		a := assert.New(t)
+
+		canonical := reflector.parseTags(typ.Field(0).Tag)
+		alias := reflector.parseTags(typ.Field(1).Tag)
+
+		a.Equal(floatPtr(1.0), alias.minimum)
+		a.Equal(floatPtr(10.0), alias.maximum)
+		a.Equal(canonical.minimum, alias.minimum)
+		a.Equal(canonical.maximum, alias.maximum)
+	})
+	t.Run("ParseTags_accepts_LengthAliases", func(t *testing.T) {
+		a := assert.New(t)
+
+		tags := reflector.parseTags(typ.Field(2).Tag)
+
+		a.Equal(intPtr(2), tags.minLength)
+		a.Equal(intPtr(20), tags.maxLength)
+	})
+	t.Run("ParseTags_ignores_StandaloneTagsByDefault", func(t *testing.T) {
+		a := assert.New(t)
+
+		tags := reflector.parseTags(typ.Field(3).Tag)
+
+		a.Nil(tags.minimum)
+		a.Nil(tags.maximum)
+	})
+	t.Run("ParseTags_accepts_StandaloneAliases", func(t *testing.T) {
+		a := assert.New(t)
+
+		tags := (&Reflector{StandaloneTags: true}).parseTags(typ.Field(3).Tag)
+
+		a.Equal(floatPtr(1.0), tags.minimum)
+		a.Equal(floatPtr(10.0), tags.maximum)
+	})
+	t.Run("Reflect_applies_AliasConstraints", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(aliasTagged{})
+
+		a.Equal(floatPtr(1.0), schema.Properties["alias"].Minimum)
+		a.Equal(floatPtr(10.0), schema.Properties["alias"].Maximum)
+		a.Equal(intPtr(2), schema.Properties["name"].MinLength)
+		a.Equal(intPtr(20), schema.Properties["name"].MaxLength)
+	})
+}
+
+type titled struct {
+	Name  string `json:"name" jsonschema:"title=The name"`
+	Email string `json:"email" jsonschema:"title=Email,description=the user's email"`
+}
+
+func TestApplyInfo(t *testing.T) {
+	t.Run("ApplyInfo_keeps_TitleByDefault", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(titled{})
+
+		a.Equal("The name", schema.Properties["name"].Title)
+		a.Empty(schema.Properties["name"].Description)
+	})
+	t.Run("ApplyInfo_copies_DescriptionFromTitle", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{DescriptionFromTitle: true}
+		schema := reflector.Reflect(titled{})
+
+		a.Equal("The name", schema.Properties["name"].Title)
+		a.Equal("The name", schema.Properties["name"].Description)
+	})
+	t.Run("ApplyInfo_swaps_TitleDescription", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{SwapTitleDescription: true}
+		schema := reflector.Reflect(titled{})
+
+		a.Empty(schema.Properties["name"].Title)
+		a.Equal("The name", schema.Properties["name"].Description)
+	})
+	t.Run("ApplyInfo_sets_Description", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(titled{})
+
+		email := schema.Properties["email"]
+		a.Equal("Email", email.Title)
+		a.Equal("the user's email", email.Description)
+
+		data, err := json.Marshal(email)
+		a.NoError(err)
+		a.Contains(string(data), `"description":"the user's email"`)
+	})
+	t.Run("ApplyInfo_keeps_DescriptionOverTitle", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{DescriptionFromTitle: true}
+		schema := reflector.Reflect(titled{})
+
+		a.Equal("the user's email", schema.Properties["email"].Description)
+	})
+}
+
+type scheduled struct {
+	Day      string `json:"day" jsonschema:"format=date"`
+	At       string `json:"at" jsonschema:"format=time"`
+	Stamp    string `json:"stamp" jsonschema:"format=date-time"`
+	Interval string `json:"interval" jsonschema:"format=duration"`
+}
+
+func TestApplyValidationFormat(t *testing.T) {
+	a := assert.New(t)
+
+	schema := Reflect(scheduled{})
+
+	a.Equal(tTypeString, schema.Properties["day"].Type)
+	a.Equal("date", schema.Properties["day"].Format)
+	a.Equal("time", schema.Properties["at"].Format)
+	a.Equal("date-time", schema.Properties["stamp"].Format)
+	a.Equal("duration", schema.Properties["interval"].Format)
+}
+
+type contact struct {
+	Contact string `json:"contact" jsonschema:"formats=email|uri"`
+}
+
+func TestApplyValidationFormats(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(contact{})
+
+	r.Contains(schema.Properties, "contact")
+	contactProperty := schema.Properties["contact"]
+	a.Equal(tTypeString, contactProperty.Type)
+	a.Empty(contactProperty.Format)
+	r.Len(contactProperty.AnyOf, 2)
+	a.Equal("email", contactProperty.AnyOf[0].Format)
+	a.Equal("uri", contactProperty.AnyOf[1].Format)
+}
+
+type login struct {
+	User     string `json:"user,omitempty" binding:"required"`
+	Password string `json:"password,omitempty" binding:"required,min=8"`
+	Remember bool   `json:"remember,omitempty" binding:"omitempty"`
+}
+
+func TestParseTagsRequiredTagKeys(t *testing.T) {
+	a := assert.New(t)
+
+	schema := Reflect(login{})
+	a.Empty(schema.Required)
+
+	reflector := &Reflector{RequiredTagKeys: []string{"binding"}}
+	schema = reflector.Reflect(login{})
+	a.Equal([]string{"user", "password"}, schema.Required)
+}
+
+type credentials struct {
+	ID       int      `json:"id" jsonschema:"readonly"`
+	Password string   `json:"password" jsonschema:"writeonly=true"`
+	Roles    []string `json:"roles" jsonschema:"uniqueitems,minitems=1"`
+	Created  string   `json:"created" jsonschema:"readOnly"`
+	Plain    string   `json:"plain"`
+}
+
+func TestParseTagsCaseInsensitive(t *testing.T) {
+	a := assert.New(t)
+
+	schema := Reflect(credentials{})
+
+	a.True(schema.Properties["id"].ReadOnly)
+	a.True(schema.Properties["password"].WriteOnly)
+	a.True(schema.Properties["roles"].UniqueItems)
+	a.Equal(intPtr(1), schema.Properties["roles"].MinItems)
+	a.True(schema.Properties["created"].ReadOnly)
+	a.False(schema.Properties["plain"].ReadOnly)
+	a.False(schema.Properties["plain"].WriteOnly)
+}
+
+type toggles struct {
+	Disabled bool `json:"disabled" jsonschema:"const=false"`
+	Enabled  bool `json:"enabled"`
+}
+
+func TestReflectBool(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(toggles{Enabled: true})
+
+	disabledProperty := schema.Properties["disabled"]
+	a.Equal(tTypeBoolean, disabledProperty.Type)
+	a.Equal(false, disabledProperty.Const)
+	a.Nil(disabledProperty.Default)
+
+	enabledProperty := schema.Properties["enabled"]
+	a.Nil(enabledProperty.Const)
+	a.Equal(true, enabledProperty.Default)
+
+	data, err := json.Marshal(disabledProperty)
+	r.NoError(err)
+	a.Contains(string(data), `"const":false`)
+	a.NotContains(string(data), `"default"`)
+}
+
+type measurement struct {
+	Value interface{} `json:"value" jsonschema:"types=string|number"`
+}
+
+func TestApplyValidationTypes(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(measurement{})
+
+	valueProperty := schema.Properties["value"]
+	a.Equal([]string{tTypeString, tTypeNumber}, valueProperty.Types)
+
+	data, err := json.Marshal(valueProperty)
+	r.NoError(err)
+	a.Contains(string(data), `"type":["string","number"]`)
+}
+
+type priority struct {
+	Level int    `json:"level" jsonschema:"enum=1|2|3,enumNames=Low|Mid|High"`
+	Color string `json:"color" jsonschema:"enum=red|green"`
+}
+
+func TestApplyInfoEnumNames(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(priority{})
+
+	levelProperty := schema.Properties["level"]
+	a.Equal([]interface{}{int64(1), int64(2), int64(3)}, levelProperty.Enum)
+	a.Equal([]string{"Low", "Mid", "High"}, levelProperty.EnumVarNames)
+
+	colorProperty := schema.Properties["color"]
+	a.Equal([]interface{}{"red", "green"}, colorProperty.Enum)
+	a.Empty(colorProperty.EnumVarNames)
+
+	data, err := json.Marshal(levelProperty)
+	r.NoError(err)
+	a.Contains(string(data), `"x-enum-varnames":["Low","Mid","High"]`)
+}
+
+type customTagged struct {
+	Host string `cfg:"host,omitempty" rules:"required,minLength=3"`
+	Port int    `cfg:"port" rules:"title=Port"`
+	Skip string `cfg:"-" rules:"required"`
+	JSON string `json:"json" jsonschema:"required"`
+}
+
+func TestParseTagsStructTagName(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	reflector := &Reflector{
+		PropertyNameTag: "yaml",
+		StructTagName: StructTagNames{
+			Property: "cfg",
+			Keywords: "rules",
+		},
+	}
+
+	schema := reflector.Reflect(customTagged{})
+
+	r.Len(schema.Properties, 2)
+	r.Contains(schema.Properties, "host")
+	a.Equal(intPtr(3), schema.Properties["host"].MinLength)
+	r.Contains(schema.Properties, "port")
+	a.Equal("Port", schema.Properties["port"].Title)
+	a.Equal([]string{"host", "port"}, schema.Required)
+}
+
+type polling struct {
+	Interval time.Duration `json:"interval" jsonschema:"enum=1s|1m|1h"`
+	Timeout  time.Duration `json:"timeout" jsonschema:"const=1m30s"`
+}
+
+func TestApplyValidationDurationEnum(t *testing.T) {
+	a := assert.New(t)
+
+	reflector := &Reflector{DurationAsString: true}
+	schema := reflector.Reflect(polling{})
+
+	intervalProperty := schema.Properties["interval"]
+	a.Equal(tTypeString, intervalProperty.Type)
+	a.Equal([]interface{}{"PT1S", "PT1M", "PT1H"}, intervalProperty.Enum)
+	a.Equal("PT1M30S", schema.Properties["timeout"].Const)
+}
+
+type bounded struct {
+	Age      int     `json:"age" jsonschema:"minimum=18,maximum=120"`
+	Weight   float64 `json:"weight" jsonschema:"minimum=18,maximum=120"`
+	Rating   int     `json:"rating" jsonschema:"minimum=1.5,multipleOf=0.5"`
+	Fraction float64 `json:"fraction" jsonschema:"minimum=1.5,maximum=2.25,multipleOf=0.25"`
+}
+
+func TestApplyValidationNumberBounds(t *testing.T) {
+	t.Run("Reflect_applies_IntegralBounds", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(bounded{})
+
+		for _, name := range []string{"age", "weight"} {
+			a.Equal(floatPtr(18.0), schema.Properties[name].Minimum, name)
+			a.Equal(floatPtr(120.0), schema.Properties[name].Maximum, name)
+		}
+		a.Equal(tTypeInteger, schema.Properties["age"].Type)
+	})
+	t.Run("Reflect_applies_FractionalBounds", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(bounded{})
+
+		a.Equal(floatPtr(1.5), schema.Properties["rating"].Minimum)
+		a.Equal(floatPtr(0.5), schema.Properties["rating"].MultipleOf)
+		a.Equal(floatPtr(1.5), schema.Properties["fraction"].Minimum)
+		a.Equal(floatPtr(2.25), schema.Properties["fraction"].Maximum)
+		a.Equal(floatPtr(0.25), schema.Properties["fraction"].MultipleOf)
+	})
+	t.Run("MarshalJSON_writes_BoundsAsNumbers", func(t *testing.T) {
+		a := assert.New(t)
+
+		data, err := json.Marshal(Reflect(bounded{}).Properties["fraction"])
+
+		a.NoError(err)
+		a.Contains(string(data), `"minimum":1.5`)
+		a.Contains(string(data), `"maximum":2.25`)
+	})
+}
+
+type quoted struct {
+	Amount  int     `json:"amount,omitempty,string"`
+	Ratio   float64 `json:"ratio,string"`
+	Enabled *bool   `json:"enabled,string,omitempty"`
+	Label   string  `json:"label,string"`
+}
+
+type quotedTuned struct {
+	Limit *int  `json:"limit,string" jsonschema:"minimum=1,enum=10|20"`
+	Port  *Port `json:"port,string"`
+}
+
+func TestParseTagsStringOption(t *testing.T) {
+	t.Run("ParseTags_detects_AllOptions", func(t *testing.T) {
+		a := assert.New(t)
+
+		tags := (&Reflector{}).parseTags(reflect.TypeOf(quoted{}).Field(0).Tag)
+
+		a.Equal("amount", tags.name)
+		a.True(tags.omitEmpty)
+		a.True(tags.asString)
+		a.False(tags.required)
+	})
+	t.Run("Reflect_describes_QuotedScalarsAsStrings", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(quoted{})
+
+		amount := schema.Properties["amount"]
+		a.Equal(tTypeString, amount.Type)
+		a.Equal(patternInteger, amount.Pattern)
+		a.Nil(amount.Default)
+		a.NotContains(schema.Required, "amount")
+
+		a.Equal(tTypeString, schema.Properties["ratio"].Type)
+		a.Equal(patternNumber, schema.Properties["ratio"].Pattern)
+		a.Equal(tTypeString, schema.Properties["enabled"].Type)
+		a.Equal(patternBoolean, schema.Properties["enabled"].Pattern)
+
+		a.Equal(tTypeString, schema.Properties["label"].Type)
+		a.Empty(schema.Properties["label"].Pattern)
+	})
+	t.Run("Reflect_quotes_ReflectedKeywords", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{Nullable: NullableTypeList}
+		schema := reflector.Reflect(quotedTuned{})
+
+		limit := schema.Properties["limit"]
+		a.Equal([]string{tTypeString, tTypeNull}, limit.Types)
+		a.Equal(patternInteger, limit.Pattern)
+		a.Equal([]interface{}{"10", "20", nil}, limit.Enum)
+		a.Equal(floatPtr(1), limit.Minimum)
+
+		port := schema.Properties["port"]
+		a.Equal([]string{tTypeString, tTypeNull}, port.Types)
+		a.Equal("8080", port.Default)
+	})
+	t.Run("Reflect_quotes_WithoutDefaults", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := (&Reflector{NoDefaults: true}).Reflect(quoted{})
+
+		a.Equal(tTypeString, schema.Properties["amount"].Type)
+		a.Equal(tTypeString, schema.Properties["label"].Type)
+	})
+}
+
+type unconstrained struct {
+	Nickname string  `json:"nickname"`
+	Score    float64 `json:"score"`
+	Code     string  `json:"code" jsonschema:"minLength=0,maxLength=8"`
+	Offset   int     `json:"offset" jsonschema:"minimum=0"`
+}
+
+func TestApplyValidationUnset(t *testing.T) {
+	t.Run("MarshalJSON_omits_UnsetKeywords", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(unconstrained{})
+
+		nickname, err := json.Marshal(schema.Properties["nickname"])
+		a.NoError(err)
+		a.NotContains(string(nickname), "minLength")
+		a.NotContains(string(nickname), "maxLength")
+
+		score, err := json.Marshal(schema.Properties["score"])
+		a.NoError(err)
+		a.NotContains(string(score), "minimum")
+		a.NotContains(string(score), "maximum")
+		a.NotContains(string(score), "multipleOf")
+	})
+	t.Run("MarshalJSON_keeps_ExplicitZero", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(unconstrained{})
+
+		code, err := json.Marshal(schema.Properties["code"])
+		a.NoError(err)
+		a.Contains(string(code), `"minLength":0`)
+		a.Contains(string(code), `"maxLength":8`)
+
+		offset, err := json.Marshal(schema.Properties["offset"])
+		a.NoError(err)
+		a.Contains(string(offset), `"minimum":0`)
+	})
+}
+
+type profile struct {
+	Name     string `json:"name"`
+	Friends  []int  `json:"friends,omitempty"`
+	Nickname string `json:"nickname" jsonschema:"required=false"`
+	Email    string `json:"email,omitempty" jsonschema:"required"`
+}
+
+func TestParseTagsOmitEmptyRequired(t *testing.T) {
+	t.Run("ParseTags_requires_FieldsWithoutOmitEmpty", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{}
+		typ := reflect.TypeOf(profile{})
+
+		a.True(reflector.parseTags(typ.Field(0).Tag).required)
+		a.False(reflector.parseTags(typ.Field(1).Tag).required)
+		a.False(reflector.parseTags(typ.Field(2).Tag).required)
+		a.True(reflector.parseTags(typ.Field(3).Tag).required)
+	})
+	t.Run("Reflect_infers_RequiredFromOmitEmpty", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(profile{})
+
+		a.Equal([]string{"name", "email"}, schema.Required)
+	})
+}
+
+type slugged struct {
+	Slug string `json:"slug" jsonschema:"pattern=^[a-z]+$"`
+}
+
+type coded struct {
+	Code string `json:"code" jsonschema:"minLength=2,pattern=^[A-Z]{2,3}$"`
+}
+
+type malformed struct {
+	Slug string `json:"slug" jsonschema:"pattern=^[a-z+$"`
+}
+
+func TestApplyValidationPattern(t *testing.T) {
+	t.Run("Reflect_sets_Pattern", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(slugged{})
+
+		a.Equal("^[a-z]+$", schema.Properties["slug"].Pattern)
+	})
+	t.Run("Reflect_keeps_CommasInPattern", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(coded{})
+
+		a.Equal("^[A-Z]{2,3}$", schema.Properties["code"].Pattern)
+		a.Equal(intPtr(2), schema.Properties["code"].MinLength)
+	})
+	t.Run("Reflect_panics_OnInvalidPattern", func(t *testing.T) {
+		a := assert.New(t)
+
+		defer func() {
+			message, _ := recover().(string)
+			a.Contains(message, `jsonschema: invalid pattern "^[a-z+$"`)
+		}()
+
+		Reflect(malformed{})
+		a.Fail("Reflect must panic")
+	})
+}
+
+type samples struct {
+	Raw    []byte   `json:"raw"`
+	Levels []uint8  `json:"levels" jsonschema:"binary=false"`
+	Digest [4]uint8 `json:"digest" jsonschema:"binary=false"`
+}
+
+func TestParseTagsBinary(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	schema := Reflect(samples{})
+
+	a.Equal(tTypeString, schema.Properties["raw"].Type)
+
+	levels := schema.Properties["levels"]
+	a.Equal(tTypeArray, levels.Type)
+	r.NotNil(levels.Items)
+	a.Equal(tTypeInteger, levels.Items.Type)
+
+	digest := schema.Properties["digest"]
+	a.Equal(tTypeArray, digest.Type)
+	a.Equal(intPtr(4), digest.MinItems)
+	a.Equal(intPtr(4), digest.MaxItems)
+}
+
+type exclusive struct {
+	Ratio  float64 `json:"ratio" jsonschema:"exclusiveMinimum=0,exclusiveMaximum=1.5"`
+	Legacy int     `json:"legacy" jsonschema:"minimum=0,exclusiveMinimum=true"`
+}
+
+func TestApplyValidationExclusiveBounds(t *testing.T) {
+	t.Run("Reflect_sets_NumericBounds", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(exclusive{})
+
+		ratio := schema.Properties["ratio"]
+		a.Equal(floatPtr(0), ratio.ExclusiveMinimumValue)
+		a.Equal(floatPtr(1.5), ratio.ExclusiveMaximumValue)
+		a.False(ratio.ExclusiveMinimum)
+		a.False(ratio.ExclusiveMaximum)
+
+		data, err := json.Marshal(ratio)
+		a.NoError(err)
+		a.Contains(string(data), `"exclusiveMinimum":0`)
+		a.Contains(string(data), `"exclusiveMaximum":1.5`)
+	})
+	t.Run("Reflect_converts_BooleanBoundsToNumbers", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(exclusive{})
+
+		legacy := schema.Properties["legacy"]
+		a.False(legacy.ExclusiveMinimum)
+		a.Nil(legacy.Minimum)
+		a.Equal(floatPtr(0), legacy.ExclusiveMinimumValue)
+
+		data, err := json.Marshal(legacy)
+		a.NoError(err)
+		a.Contains(string(data), `"exclusiveMinimum":0`)
+		a.NotContains(string(data), "exclusiveMaximum")
+	})
+	t.Run("Reflect_writes_BooleanBoundsForDraft04", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := (&Reflector{Draft: Draft04}).Reflect(exclusive{})
+
+		ratio := schema.Properties["ratio"]
+		a.Equal(floatPtr(0), ratio.Minimum)
+		a.Equal(floatPtr(1.5), ratio.Maximum)
+		a.True(ratio.ExclusiveMinimum)
+		a.True(ratio.ExclusiveMaximum)
+		a.Nil(ratio.ExclusiveMinimumValue)
+		a.Nil(ratio.ExclusiveMaximumValue)
+
+		legacy := schema.Properties["legacy"]
+		a.Equal(floatPtr(0), legacy.Minimum)
+		a.True(legacy.ExclusiveMinimum)
+
+		data, err := json.Marshal(ratio)
+		a.NoError(err)
+		a.Contains(string(data), `"exclusiveMinimum":true`)
+		a.Contains(string(data), `"minimum":0`)
+	})
+}
+
+type versioned struct {
+	APIVersion string  `json:"apiVersion" jsonschema:"const=v1"`
+	Revision   int     `json:"revision" jsonschema:"const=2"`
+	Zero       int     `json:"zero" jsonschema:"const=0"`
+	Scale      float64 `json:"scale" jsonschema:"const=1.5"`
+}
+
+type unversioned struct {
+	Malformed int `json:"malformed" jsonschema:"const=two"`
+}
+
+func TestApplyValidationConst(t *testing.T) {
+	t.Run("Reflect_coerces_ConstToFieldType", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(versioned{})
+
+		a.Equal("v1", schema.Properties["apiVersion"].Const)
+		a.Equal(int64(2), schema.Properties["revision"].Const)
+		a.Equal(int64(0), schema.Properties["zero"].Const)
+		a.Equal(1.5, schema.Properties["scale"].Const)
+	})
+	t.Run("Reflect_panics_OnMistypedConst", func(t *testing.T) {
+		a := assert.New(t)
+
+		defer func() {
+			message, _ := recover().(string)
+			a.Contains(message, `jsonschema: invalid const value "two" for type integer`)
+		}()
+
+		Reflect(unversioned{})
+		a.Fail("Reflect must panic")
+	})
+	t.Run("MarshalJSON_writes_TypedConst", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(versioned{})
+
+		for name, expected := range map[string]string{
+			"apiVersion": `"const":"v1"`,
+			"revision":   `"const":2`,
+			"zero":       `"const":0`,
+		} {
+			data, err := json.Marshal(schema.Properties[name])
+			a.NoError(err)
+			a.Contains(string(data), expected)
+		}
+	})
+}
+
+type mistyped struct {
+	Mixed int `json:"mixed" jsonschema:"enum=1|two|3"`
+}
+
+type palette struct {
+	Color    string  `json:"color" jsonschema:"enum=red|green|blue"`
+	Size     int     `json:"size" jsonschema:"enum=1|2|3"`
+	Optional *int    `json:"optional,omitempty" jsonschema:"enum=1|2|3"`
+	Ratio    float64 `json:"ratio" jsonschema:"enum=0.5|1"`
+}
+
+func TestApplyValidationEnum(t *testing.T) {
+	t.Run("Reflect_coerces_EnumToFieldType", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(palette{})
+
+		a.Equal([]interface{}{"red", "green", "blue"}, schema.Properties["color"].Enum)
+		a.Equal([]interface{}{int64(1), int64(2), int64(3)}, schema.Properties["size"].Enum)
+		a.Equal([]interface{}{int64(1), int64(2), int64(3)}, schema.Properties["optional"].Enum)
+		a.Equal([]interface{}{0.5, 1.0}, schema.Properties["ratio"].Enum)
+	})
+	t.Run("Reflect_panics_OnMistypedEntries", func(t *testing.T) {
+		a := assert.New(t)
+
+		defer func() {
+			message, _ := recover().(string)
+			a.Contains(message, `jsonschema: invalid enum value "two" for type integer`)
+		}()
+
+		Reflect(mistyped{})
+		a.Fail("Reflect must panic")
+	})
+	t.Run("MarshalJSON_writes_TypedEnum", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(palette{})
+
+		color, err := json.Marshal(schema.Properties["color"])
+		a.NoError(err)
+		a.Contains(string(color), `"enum":["red","green","blue"]`)
+
+		size, err := json.Marshal(schema.Properties["size"])
+		a.NoError(err)
+		a.Contains(string(size), `"enum":[1,2,3]`)
+	})
+}
+
+type pagination struct {
+	Limit  int    `json:"limit" jsonschema:"default=20"`
+	Order  string `json:"order" jsonschema:"default=asc"`
+	Strict bool   `json:"strict" jsonschema:"default=true"`
+	Offset int    `json:"offset"`
+	Cursor string `json:"cursor"`
+}
+
+func TestApplyInfoDefault(t *testing.T) {
+	t.Run("Reflect_prefers_TagDefaultOverFieldValue", func(t *testing.T) {
+		a := assert.New(t)
+
+		schema := Reflect(pagination{Limit: 50, Order: "desc", Offset: 10})
+
+		a.Equal(int64(20), schema.Properties["limit"].Default)
+		a.Equal("asc", schema.Properties["order"].Default)
+		a.Equal(true, schema.Properties["strict"].Default)
+		a.Equal(10, schema.Properties["offset"].Default)
+	})
+	t.Run("Reflect_strips_ReflectedDefaultsWithNoDefaults", func(t *testing.T) {
+		a := assert.New(t)
+
+		reflector := &Reflector{NoDefaults: true}
+
+		schema := reflector.Reflect(pagination{Offset: 10, Cursor: "abc"})
+		a.Nil(schema.Properties["offset"].Default)
+		a.Nil(schema.Properties["cursor"].Default)
+		a.Equal(int64(20), schema.Properties["limit"].Default)
+
+		schema = reflector.Reflect(&TestUser{ID: 1, Name: "ann"})
+		for _, typ := range []*Type{schema.Type, schema.Definitions["GrandfatherType"]} {
+			typ.walk(func(typ *Type) {
+				a.Nil(typ.Default)
+			})
+		}
+	})
+}
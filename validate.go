@@ -0,0 +1,508 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError describes a value violating a schema keyword, or a
+// schema contradicting itself.
+type ValidationError struct {
+	Path    string // JSON Pointer to the value or sub-schema, empty for the root
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("#%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors lists every violation found in a document.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return "jsonschema: " + strings.Join(messages, "; ")
+}
+
+// ValidateJSON validates a JSON document against the schema, resolving
+// references from its definitions. It returns ValidationErrors describing
+// each violation, or nil when the document is valid.
+// Supported are the keywords the reflector emits: type, enum, const,
+// string, number, array and object constraints, and the allOf, anyOf,
+// oneOf, not and if/then/else combinators.
+func (s *Schema) ValidateJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("jsonschema: invalid document: %v", err)
+	}
+
+	v := &validator{definitions: s.Definitions}
+	v.validate(s.Type, value, "")
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+
+	return nil
+}
+
+type validator struct {
+	definitions Definitions
+	errs        ValidationErrors
+}
+
+func (v *validator) fail(path string, format string, args ...interface{}) {
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// valid reports whether value is valid against t, without recording errors.
+func (v *validator) valid(t *Type, value interface{}, path string) bool {
+	scratch := &validator{definitions: v.definitions}
+	scratch.validate(t, value, path)
+
+	return len(scratch.errs) == 0
+}
+
+func (v *validator) validate(t *Type, value interface{}, path string) {
+	if t == nil {
+		return
+	}
+
+	if t.Ref != "" {
+		name, ok := definitionName(t.Ref)
+		if def, found := v.definitions[name]; ok && found {
+			v.validate(def, value, path)
+		} else {
+			v.fail(path, "unresolvable $ref %q", t.Ref)
+		}
+	}
+
+	if !v.validateType(t, value, path) {
+		return // further keywords don't apply to values of another type
+	}
+
+	if len(t.Enum) > 0 && !containsValue(t.Enum, value) {
+		v.fail(path, "%s is not one of the enum values", describe(value))
+	}
+
+	if t.Const != nil && !reflect.DeepEqual(normalize(t.Const), value) {
+		v.fail(path, "%s is not the const value %s", describe(value), describe(normalize(t.Const)))
+	}
+
+	switch value := value.(type) {
+	case string:
+		v.validateString(t, value, path)
+	case float64:
+		v.validateNumber(t, value, path)
+	case []interface{}:
+		v.validateArray(t, value, path)
+	case map[string]interface{}:
+		v.validateObject(t, value, path)
+	}
+
+	v.validateCombinators(t, value, path)
+}
+
+func (v *validator) validateType(t *Type, value interface{}, path string) bool {
+	types := t.Types
+	if len(types) == 0 && t.Type != "" {
+		types = []string{t.Type}
+	}
+
+	if len(types) == 0 {
+		return true
+	}
+
+	actual := jsonTypeOf(value)
+	for _, typ := range types {
+		if typ == actual || typ == tTypeNumber && actual == tTypeInteger {
+			return true
+		}
+	}
+
+	v.fail(path, "%s is not of type %s", describe(value), strings.Join(types, " or "))
+
+	return false
+}
+
+func (v *validator) validateString(t *Type, value string, path string) {
+	length := utf8.RuneCountInString(value)
+
+	if t.MinLength != nil && length < *t.MinLength {
+		v.fail(path, "length %d is less than the minLength %d", length, *t.MinLength)
+	}
+
+	if t.MaxLength != nil && length > *t.MaxLength {
+		v.fail(path, "length %d is greater than the maxLength %d", length, *t.MaxLength)
+	}
+
+	if t.Pattern != "" {
+		pattern, err := regexp.Compile(t.Pattern)
+		if err != nil {
+			v.fail(path, "invalid pattern %q: %v", t.Pattern, err)
+		} else if !pattern.MatchString(value) {
+			v.fail(path, "%q does not match the pattern %q", value, t.Pattern)
+		}
+	}
+}
+
+func (v *validator) validateNumber(t *Type, value float64, path string) {
+	if t.Minimum != nil {
+		if t.ExclusiveMinimum && value <= *t.Minimum {
+			v.fail(path, "%v is not greater than the exclusive minimum %v", value, *t.Minimum)
+		} else if value < *t.Minimum {
+			v.fail(path, "%v is less than the minimum %v", value, *t.Minimum)
+		}
+	}
+
+	if t.Maximum != nil {
+		if t.ExclusiveMaximum && value >= *t.Maximum {
+			v.fail(path, "%v is not less than the exclusive maximum %v", value, *t.Maximum)
+		} else if value > *t.Maximum {
+			v.fail(path, "%v is greater than the maximum %v", value, *t.Maximum)
+		}
+	}
+
+	if t.ExclusiveMinimumValue != nil && value <= *t.ExclusiveMinimumValue {
+		v.fail(path, "%v is not greater than the exclusive minimum %v", value, *t.ExclusiveMinimumValue)
+	}
+
+	if t.ExclusiveMaximumValue != nil && value >= *t.ExclusiveMaximumValue {
+		v.fail(path, "%v is not less than the exclusive maximum %v", value, *t.ExclusiveMaximumValue)
+	}
+
+	if t.MultipleOf != nil && *t.MultipleOf != 0 {
+		if quotient := value / *t.MultipleOf; quotient != math.Trunc(quotient) {
+			v.fail(path, "%v is not a multiple of %v", value, *t.MultipleOf)
+		}
+	}
+}
+
+func (v *validator) validateArray(t *Type, value []interface{}, path string) {
+	if t.MinItems != nil && len(value) < *t.MinItems {
+		v.fail(path, "%d items are less than the minItems %d", len(value), *t.MinItems)
+	}
+
+	if t.MaxItems != nil && len(value) > *t.MaxItems {
+		v.fail(path, "%d items are more than the maxItems %d", len(value), *t.MaxItems)
+	}
+
+	if t.UniqueItems {
+		for i := range value {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(value[i], value[j]) {
+					v.fail(path, "items %d and %d are equal", j, i)
+				}
+			}
+		}
+	}
+
+	for i, item := range value {
+		items := t.Items
+		switch {
+		case t.TupleItems != nil:
+			items = nil
+			if i < len(t.TupleItems) {
+				items = t.TupleItems[i]
+			}
+		case i < len(t.PrefixItems):
+			items = t.PrefixItems[i]
+		}
+
+		v.validate(items, item, fmt.Sprintf("%s/%d", path, i))
+	}
+}
+
+func (v *validator) validateObject(t *Type, value map[string]interface{}, path string) {
+	if t.MinProperties != 0 && len(value) < t.MinProperties {
+		v.fail(path, "%d properties are less than the minProperties %d", len(value), t.MinProperties)
+	}
+
+	if t.MaxProperties != 0 && len(value) > t.MaxProperties {
+		v.fail(path, "%d properties are more than the maxProperties %d", len(value), t.MaxProperties)
+	}
+
+	for _, name := range t.Required {
+		if _, ok := value[name]; !ok {
+			v.fail(path, "required property %q is missing", name)
+		}
+	}
+
+	closed := string(bytes.TrimSpace(t.AdditionalProperties)) == "false"
+
+	// sorted for errors in a stable order
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propertyPath := path + "/" + escapeJSONPointer(name)
+
+		if t.PropertyNames != nil {
+			v.validate(t.PropertyNames, name, propertyPath)
+		}
+
+		matched := false
+		if property, ok := t.Properties[name]; ok {
+			matched = true
+			v.validate(property, value[name], propertyPath)
+		}
+
+		for pattern, property := range t.PatternProperties {
+			expr, err := regexp.Compile(pattern)
+			if err != nil {
+				v.fail(path, "invalid patternProperties pattern %q: %v", pattern, err)
+				continue
+			}
+			if expr.MatchString(name) {
+				matched = true
+				v.validate(property, value[name], propertyPath)
+			}
+		}
+
+		if !matched && closed {
+			v.fail(propertyPath, "additional property %q is not allowed", name)
+		}
+	}
+}
+
+func (v *validator) validateCombinators(t *Type, value interface{}, path string) {
+	for _, typ := range t.AllOf {
+		v.validate(typ, value, path)
+	}
+
+	if len(t.AnyOf) > 0 {
+		matched := false
+		for _, typ := range t.AnyOf {
+			if v.valid(typ, value, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "%s matches none of the anyOf schemas", describe(value))
+		}
+	}
+
+	if len(t.OneOf) > 0 {
+		matched := 0
+		for _, typ := range t.OneOf {
+			if v.valid(typ, value, path) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.fail(path, "%s matches %d of the oneOf schemas instead of exactly one", describe(value), matched)
+		}
+	}
+
+	if t.Not != nil && v.valid(t.Not, value, path) {
+		v.fail(path, "%s matches the not schema", describe(value))
+	}
+
+	if t.If != nil {
+		if v.valid(t.If, value, path) {
+			v.validate(t.Then, value, path)
+		} else {
+			v.validate(t.Else, value, path)
+		}
+	}
+}
+
+// jsonTypeOf returns the JSON type of a decoded value, integer for
+// numbers without a fractional part.
+func jsonTypeOf(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return tTypeNull
+	case bool:
+		return tTypeBoolean
+	case string:
+		return tTypeString
+	case float64:
+		if value == math.Trunc(value) {
+			return tTypeInteger
+		}
+		return tTypeNumber
+	case []interface{}:
+		return tTypeArray
+	}
+
+	return tTypeObject
+}
+
+// normalize converts a Go value to its decoded JSON form, so typed enum
+// and const values compare equal to decoded documents.
+func normalize(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+
+	return normalized
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(normalize(v), value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// describe formats a decoded value for error messages.
+func describe(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(data)
+}
+
+// CheckConsistency checks t and its sub-schemas for contradicting
+// keywords, such as a minLength greater than the maxLength or a const
+// missing from the enum, which no value could satisfy. It returns
+// ValidationErrors describing each contradiction, or nil when there is
+// none.
+func (t *Type) CheckConsistency() error {
+	var errs ValidationErrors
+	t.walkPath("", func(path string, typ *Type) {
+		errs = append(errs, typ.contradictions(path)...)
+	})
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// CheckConsistency is like Type.CheckConsistency, also checking the
+// definitions.
+func (s *Schema) CheckConsistency() error {
+	var errs ValidationErrors
+	if err, ok := s.Type.CheckConsistency().(ValidationErrors); ok {
+		errs = append(errs, err...)
+	}
+
+	names := make([]string, 0, len(s.Definitions))
+	for name := range s.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err, ok := s.Definitions[name].CheckConsistency().(ValidationErrors); ok {
+			for _, e := range err {
+				e.Path = "/" + s.Draft.definitionsKeyword() + "/" + escapeJSONPointer(name) + e.Path
+				errs = append(errs, e)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// contradictions lists the contradicting keywords of t itself.
+func (t *Type) contradictions(path string) ValidationErrors {
+	var errs ValidationErrors
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if t.MinLength != nil && t.MaxLength != nil && *t.MinLength > *t.MaxLength {
+		fail("minLength %d is greater than maxLength %d", *t.MinLength, *t.MaxLength)
+	}
+
+	if t.MinItems != nil && t.MaxItems != nil && *t.MinItems > *t.MaxItems {
+		fail("minItems %d is greater than maxItems %d", *t.MinItems, *t.MaxItems)
+	}
+
+	if t.MaxProperties != 0 && t.MinProperties > t.MaxProperties {
+		fail("minProperties %d is greater than maxProperties %d", t.MinProperties, t.MaxProperties)
+	}
+
+	if t.MultipleOf != nil && *t.MultipleOf <= 0 {
+		fail("multipleOf %v is not greater than 0", *t.MultipleOf)
+	}
+
+	lower, lowerExclusive := t.lowerBound()
+	upper, upperExclusive := t.upperBound()
+	if lower != nil && upper != nil {
+		if *lower > *upper || *lower == *upper && (lowerExclusive || upperExclusive) {
+			fail("no number is within the lower bound %v and the upper bound %v", *lower, *upper)
+		}
+	}
+
+	if t.Pattern != "" {
+		if _, err := regexp.Compile(t.Pattern); err != nil {
+			fail("invalid pattern %q: %v", t.Pattern, err)
+		}
+	}
+
+	for pattern := range t.PatternProperties {
+		if _, err := regexp.Compile(pattern); err != nil {
+			fail("invalid patternProperties pattern %q: %v", pattern, err)
+		}
+	}
+
+	if t.Const != nil && len(t.Enum) > 0 && !containsValue(t.Enum, normalize(t.Const)) {
+		fail("const %s is not one of the enum values", describe(normalize(t.Const)))
+	}
+
+	if string(bytes.TrimSpace(t.AdditionalProperties)) == "false" && len(t.PatternProperties) == 0 {
+		for _, name := range t.Required {
+			if _, ok := t.Properties[name]; !ok {
+				fail("required property %q is not declared while additionalProperties is false", name)
+			}
+		}
+	}
+
+	return errs
+}
+
+// lowerBound returns the greatest lower bound of numbers, and whether it
+// is exclusive.
+func (t *Type) lowerBound() (*float64, bool) {
+	bound, exclusive := t.Minimum, t.ExclusiveMinimum && t.Minimum != nil
+	if v := t.ExclusiveMinimumValue; v != nil && (bound == nil || *v >= *bound) {
+		bound, exclusive = v, true
+	}
+
+	return bound, exclusive
+}
+
+// upperBound is like lowerBound for the least upper bound.
+func (t *Type) upperBound() (*float64, bool) {
+	bound, exclusive := t.Maximum, t.ExclusiveMaximum && t.Maximum != nil
+	if v := t.ExclusiveMaximumValue; v != nil && (bound == nil || *v <= *bound) {
+		bound, exclusive = v, true
+	}
+
+	return bound, exclusive
+}
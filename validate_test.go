@@ -0,0 +1,188 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validateAddress struct {
+	City string `json:"city" jsonschema:"minLength=1"`
+	Zip  string `json:"zip,omitempty" jsonschema:"pattern=^[0-9]{5}$"`
+}
+
+type validatePerson struct {
+	Name    string          `json:"name" jsonschema:"required"`
+	Age     int             `json:"age" jsonschema:"minimum=18,maximum=120"`
+	Role    string          `json:"role,omitempty" jsonschema:"enum=admin|user"`
+	Address validateAddress `json:"address,omitempty"`
+	Tags    []string        `json:"tags,omitempty" jsonschema:"maxItems=2"`
+}
+
+func TestSchema_ValidateJSON(t *testing.T) {
+	schema := new(Reflector).Reflect(&validatePerson{})
+
+	t.Run("ValidateJSON_accepts_valid_document", func(t *testing.T) {
+		err := schema.ValidateJSON([]byte(`{"name":"ann","age":30,"role":"admin","address":{"city":"Oslo","zip":"01234"},"tags":["a"]}`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("ValidateJSON_reports_missing_required_field", func(t *testing.T) {
+		r := require.New(t)
+
+		err := schema.ValidateJSON([]byte(`{"age":30}`))
+		r.Error(err)
+
+		errs, ok := err.(ValidationErrors)
+		r.True(ok)
+		r.Len(errs, 1)
+		r.Equal("", errs[0].Path)
+		r.Contains(errs[0].Message, `"name"`)
+	})
+
+	t.Run("ValidateJSON_reports_out_of_range_number", func(t *testing.T) {
+		r := require.New(t)
+
+		err := schema.ValidateJSON([]byte(`{"name":"ann","age":150}`))
+		r.Error(err)
+
+		errs := err.(ValidationErrors)
+		r.Len(errs, 1)
+		r.Equal("/age", errs[0].Path)
+		r.Contains(errs[0].Message, "maximum 120")
+		r.Contains(err.Error(), "#/age: ")
+	})
+
+	t.Run("ValidateJSON_resolves_references_and_collects_every_violation", func(t *testing.T) {
+		a := assert.New(t)
+
+		err := schema.ValidateJSON([]byte(`{"name":1,"age":12.5,"role":"root","address":{"city":"","zip":"x"},"tags":["a","b","c"]}`))
+
+		errs, ok := err.(ValidationErrors)
+		a.True(ok)
+
+		paths := make([]string, 0, len(errs))
+		for _, e := range errs {
+			paths = append(paths, e.Path)
+		}
+		a.ElementsMatch([]string{"/address/city", "/address/zip", "/age", "/name", "/role", "/tags"}, paths)
+	})
+
+	t.Run("ValidateJSON_rejects_malformed_document", func(t *testing.T) {
+		err := schema.ValidateJSON([]byte(`{`))
+
+		_, ok := err.(ValidationErrors)
+		assert.Error(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestCheckConsistency(t *testing.T) {
+	t.Run("CheckConsistency_accepts_ReflectedSchemas", func(t *testing.T) {
+		a := assert.New(t)
+
+		a.NoError(Reflect(&TestUser{}).CheckConsistency())
+		a.NoError(Reflect(&validatePerson{}).CheckConsistency())
+	})
+
+	for name, test := range map[string]struct {
+		typ     *Type
+		message string
+	}{
+		"Length": {
+			typ:     &Type{Type: tTypeString, MinLength: intPtr(5), MaxLength: intPtr(2)},
+			message: "minLength 5 is greater than maxLength 2",
+		},
+		"Items": {
+			typ:     &Type{Type: tTypeArray, MinItems: intPtr(3), MaxItems: intPtr(1)},
+			message: "minItems 3 is greater than maxItems 1",
+		},
+		"Properties": {
+			typ:     &Type{Type: tTypeObject, MinProperties: 4, MaxProperties: 2},
+			message: "minProperties 4 is greater than maxProperties 2",
+		},
+		"Bounds": {
+			typ:     &Type{Type: tTypeNumber, Minimum: floatPtr(10), Maximum: floatPtr(1)},
+			message: "no number is within the lower bound 10 and the upper bound 1",
+		},
+		"ExclusiveBounds": {
+			typ:     &Type{Type: tTypeNumber, Minimum: floatPtr(1), ExclusiveMaximumValue: floatPtr(1)},
+			message: "no number is within the lower bound 1 and the upper bound 1",
+		},
+		"ExclusiveFlags": {
+			typ:     &Type{Type: tTypeNumber, Minimum: floatPtr(1), Maximum: floatPtr(1), ExclusiveMinimum: true},
+			message: "no number is within the lower bound 1 and the upper bound 1",
+		},
+		"MultipleOf": {
+			typ:     &Type{Type: tTypeNumber, MultipleOf: floatPtr(0)},
+			message: "multipleOf 0 is not greater than 0",
+		},
+		"Pattern": {
+			typ:     &Type{Type: tTypeString, Pattern: "("},
+			message: `invalid pattern "("`,
+		},
+		"Const": {
+			typ:     &Type{Type: tTypeString, Const: "c", Enum: []interface{}{"a", "b"}},
+			message: `const "c" is not one of the enum values`,
+		},
+		"Required": {
+			typ:     &Type{Type: tTypeObject, Required: []string{"id"}, AdditionalProperties: []byte("false")},
+			message: `required property "id" is not declared while additionalProperties is false`,
+		},
+	} {
+		test := test
+
+		t.Run("CheckConsistency_reports_Contradicting"+name, func(t *testing.T) {
+			r := require.New(t)
+
+			err := test.typ.CheckConsistency()
+
+			errs, ok := err.(ValidationErrors)
+			r.True(ok)
+			r.Len(errs, 1)
+			r.Equal("", errs[0].Path)
+			r.Contains(errs[0].Message, test.message)
+		})
+	}
+
+	t.Run("CheckConsistency_reports_NestedContradictions", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := &Schema{
+			Type: &Type{
+				Type: tTypeObject,
+				Properties: map[string]*Type{
+					"a/b": {Type: tTypeArray, Items: &Type{Type: tTypeString, MinLength: intPtr(2), MaxLength: intPtr(1)}},
+				},
+			},
+			Definitions: Definitions{
+				"Range": {Type: tTypeInteger, Minimum: floatPtr(2), Maximum: floatPtr(1)},
+			},
+		}
+
+		errs, ok := schema.CheckConsistency().(ValidationErrors)
+		r.True(ok)
+		r.Len(errs, 2)
+		a.Equal("/properties/a~1b/items", errs[0].Path)
+		a.Equal("/definitions/Range", errs[1].Path)
+	})
+	t.Run("CheckConsistency_reports_DefsPathsPerDraft", func(t *testing.T) {
+		a := assert.New(t)
+		r := require.New(t)
+
+		schema := &Schema{
+			Type: &Type{Type: tTypeObject},
+			Definitions: Definitions{
+				"Range": {Type: tTypeInteger, Minimum: floatPtr(2), Maximum: floatPtr(1)},
+			},
+			Draft: Draft202012,
+		}
+
+		errs, ok := schema.CheckConsistency().(ValidationErrors)
+		r.True(ok)
+		r.Len(errs, 1)
+		a.Equal("/$defs/Range", errs[0].Path)
+	})
+}